@@ -5,6 +5,9 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	firebase "firebase.google.com/go/v4"
 	"firebase.google.com/go/v4/auth"
@@ -12,18 +15,37 @@ import (
 )
 
 type Paths struct {
-	DataDir      string
-	UploadsDir   string
-	PostsFile    string
-	TagsFile     string
-	FriendsFile  string
-	ProfilesFile string
-	LikesFile    string
+	DataDir            string
+	UploadsDir         string
+	PostsFile          string
+	TagsFile           string
+	MutesFile          string // 🔻 新增：靜音名單
+	FriendsFile        string
+	FollowRequestsFile string // 🔻 新增：私人帳號的追蹤請求
+	ProfilesFile       string
+	LikesFile          string
 
 	// 🔻 新增
 	BoardsFile        string
 	ConversationsFile string
 	MessagesFile      string
+
+	// 🔻 新增：每位成員自己的對話設定（靜音 / 封存 / 置頂 / 暱稱）
+	ConversationSettingsFile string
+
+	// 🔻 新增：封鎖名單
+	BlocksFile string
+
+	// 🔻 新增：連結身分（email / UID → 主帳號）
+	IdentitiesFile string
+
+	// 🔻 新增：刪除帳號（排程中的申請、已執行的清除紀錄）
+	DeletionsFile    string
+	ErasureAuditFile string
+
+	// 🔻 新增：個人資料匯出（紀錄與 zip 檔）
+	ExportsFile string
+	ExportsDir  string
 }
 
 func DefaultPaths() Paths {
@@ -35,18 +57,29 @@ func DefaultPaths() Paths {
 		}
 	}
 	return Paths{
-		DataDir:      dataDir,
-		UploadsDir:   filepath.Join(dataDir, "uploads"),
-		PostsFile:    filepath.Join(dataDir, "posts.json"),
-		TagsFile:     filepath.Join(dataDir, "tags.json"),
-		FriendsFile:  filepath.Join(dataDir, "friends.json"),
-		ProfilesFile: filepath.Join(dataDir, "profiles.json"),
-		LikesFile:    filepath.Join(dataDir, "likes.json"),
+		DataDir:            dataDir,
+		UploadsDir:         filepath.Join(dataDir, "uploads"),
+		PostsFile:          filepath.Join(dataDir, "posts.json"),
+		TagsFile:           filepath.Join(dataDir, "tags.json"),
+		MutesFile:          filepath.Join(dataDir, "mutes.json"),
+		FriendsFile:        filepath.Join(dataDir, "friends.json"),
+		FollowRequestsFile: filepath.Join(dataDir, "follow_requests.json"),
+		ProfilesFile:       filepath.Join(dataDir, "profiles.json"),
+		LikesFile:          filepath.Join(dataDir, "likes.json"),
 
 		// 🔻 新增
 		BoardsFile:        filepath.Join(dataDir, "boards.json"),
 		ConversationsFile: filepath.Join(dataDir, "conversations.json"),
 		MessagesFile:      filepath.Join(dataDir, "messages.json"),
+
+		// 🔻 新增
+		ConversationSettingsFile: filepath.Join(dataDir, "conversation_settings.json"),
+		BlocksFile:               filepath.Join(dataDir, "blocks.json"),
+		IdentitiesFile:           filepath.Join(dataDir, "identities.json"),
+		DeletionsFile:            filepath.Join(dataDir, "account_deletions.json"),
+		ErasureAuditFile:         filepath.Join(dataDir, "erasure_audit.jsonl"),
+		ExportsFile:              filepath.Join(dataDir, "exports.json"),
+		ExportsDir:               filepath.Join(dataDir, "exports"),
 	}
 }
 
@@ -59,6 +92,78 @@ func NoAuth() bool {
 	return true // ← 先強制開發模式
 }
 
+// Board 軟刪除後可復原的天數（BOARD_RESTORE_DAYS，預設 30 天）
+func BoardRestoreWindow() time.Duration {
+	days := 30
+	if v, err := strconv.Atoi(os.Getenv("BOARD_RESTORE_DAYS")); err == nil && v >= 0 {
+		days = v
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// 申請刪除帳號後的緩衝天數，期間內可以取消（ACCOUNT_DELETION_DAYS，預設 14 天）
+func AccountDeletionGrace() time.Duration {
+	days := 14
+	if v, err := strconv.Atoi(os.Getenv("ACCOUNT_DELETION_DAYS")); err == nil && v >= 0 {
+		days = v
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// 刪除帳號時貼文 / 留言的處理方式（ERASURE_POLICY）：
+// "delete"（預設）整篇刪除；"anonymize" 保留內容、作者改成「已刪除的使用者」
+func ErasureAnonymize() bool {
+	return strings.EqualFold(strings.TrimSpace(os.Getenv("ERASURE_POLICY")), "anonymize")
+}
+
+// 大頭貼允許的外部來源（AVATAR_ORIGINS，逗號分隔的 https origin）；站內 /uploads/ 一律允許
+func AvatarOrigins() []string {
+	v := os.Getenv("AVATAR_ORIGINS")
+	if strings.TrimSpace(v) == "" {
+		return []string{
+			"https://lh3.googleusercontent.com",      // Google 帳號頭像
+			"https://firebasestorage.googleapis.com", // Firebase Storage
+			"https://graph.facebook.com",
+			"https://profile.line-scdn.net",
+		}
+	}
+	out := []string{}
+	for _, o := range strings.Split(v, ",") {
+		if o = strings.TrimRight(strings.TrimSpace(o), "/"); o != "" {
+			out = append(out, strings.ToLower(o))
+		}
+	}
+	return out
+}
+
+// /stream 的 WebSocket 允許的 Origin（STREAM_ORIGINS，逗號分隔，例如 https://app.example.com）；
+// 沒設定時只接受同源或不帶 Origin 的（非瀏覽器）用戶端
+func StreamOrigins() []string {
+	out := []string{}
+	for _, o := range strings.Split(os.Getenv("STREAM_ORIGINS"), ",") {
+		if o = strings.TrimRight(strings.TrimSpace(o), "/"); o != "" {
+			out = append(out, strings.ToLower(o))
+		}
+	}
+	return out
+}
+
+// 管理員名單：ADMIN_IDS 以逗號分隔，內容是「身分鍵」（email 小寫 或 uid）
+func AdminIDs() map[string]struct{} {
+	out := map[string]struct{}{}
+	for _, id := range strings.Split(os.Getenv("ADMIN_IDS"), ",") {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			continue
+		}
+		if strings.Contains(id, "@") {
+			id = strings.ToLower(id)
+		}
+		out[id] = struct{}{}
+	}
+	return out
+}
+
 // Firebase Auth（保留；NO_AUTH=1 則不啟用）
 func NewAuthClient() *auth.Client {
 	if NoAuth() {
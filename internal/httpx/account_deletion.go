@@ -0,0 +1,152 @@
+package httpx
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"local.dev/socialdemo-backend/internal/config"
+	"local.dev/socialdemo-backend/internal/models"
+	"local.dev/socialdemo-backend/internal/store"
+)
+
+// ===== 刪除帳號 =====
+// DELETE /me              → 回一組確認碼（15 分鐘內有效）
+// DELETE /me?confirm=xxx  → 確認，緩衝期（ACCOUNT_DELETION_DAYS）過後清除；緩衝期為 0 時立即清除
+// GET    /me/deletion     → 目前的申請
+// DELETE /me/deletion     → 取消申請
+
+// ERASURE_POLICY=anonymize 時保留下來的貼文 / 留言顯示的作者名稱
+const erasedUserName = "Deleted user"
+
+func handleDeleteMe(app *AppCtx, w http.ResponseWriter, r *http.Request, uid string) {
+	now := time.Now().UTC()
+	if d, ok := app.Store.GetDeletion(uid); ok && d.ConfirmedAt != "" {
+		writeJSON(w, http.StatusConflict, map[string]any{"error": "account deletion already scheduled", "deletion": d})
+		return
+	}
+
+	token := r.URL.Query().Get("confirm")
+	if token == "" {
+		d := app.Store.RequestDeletion(uid, now)
+		app.Store.SaveDeletions(app.Paths.DeletionsFile)
+		writeJSON(w, http.StatusAccepted, map[string]any{
+			"status":         "confirmation_required",
+			"confirmToken":   d.ConfirmToken,
+			"confirmExpires": d.ConfirmExpires,
+			"graceDays":      int(config.AccountDeletionGrace() / (24 * time.Hour)),
+		})
+		return
+	}
+
+	d, err := app.Store.ConfirmDeletion(uid, token, now, config.AccountDeletionGrace())
+	switch {
+	case errors.Is(err, store.ErrDeletionNotRequested), errors.Is(err, store.ErrDeletionToken):
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	case err != nil:
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	app.Store.SaveDeletions(app.Paths.DeletionsFile)
+	log.Printf("[account-deletion] scheduled publicId=%s purgeAt=%s", pubID(app, uid), d.PurgeAt)
+
+	if !now.Before(parseISO(d.PurgeAt)) && eraseAccount(app, uid, d) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "erased"})
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]any{"status": "scheduled", "deletion": d})
+}
+
+func HandleMyDeletion(app *AppCtx) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uid := currentUID(r)
+		switch r.Method {
+		case http.MethodGet:
+			d, ok := app.Store.GetDeletion(uid)
+			if !ok {
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": "no deletion request"})
+				return
+			}
+			d.ConfirmToken = "" // 確認碼只在申請當下回傳一次
+			writeJSON(w, http.StatusOK, d)
+		case http.MethodDelete:
+			if !app.Store.CancelDeletion(uid) {
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": "no deletion request"})
+				return
+			}
+			app.Store.SaveDeletions(app.Paths.DeletionsFile)
+			log.Printf("[account-deletion] cancelled publicId=%s", pubID(app, uid))
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// 清除稽核紀錄：一行一筆 JSON，只留 PublicID 與身分鍵的雜湊，不留 email / UID 原文
+type erasureAudit struct {
+	PublicID    string `json:"publicId"`
+	SubjectHash string `json:"subjectHash"`
+	RequestedAt string `json:"requestedAt"`
+	ConfirmedAt string `json:"confirmedAt"`
+	ErasedAt    string `json:"erasedAt"`
+	store.ErasureResult
+	Files   int  `json:"files"`
+	Library bool `json:"library"`
+}
+
+// 清除帳號；有匯出還在打包時先不清（打包會讀這個人的資料），回傳 false 由背景工作下一輪再試
+func eraseAccount(app *AppCtx, uid string, d models.AccountDeletion) bool {
+	if app.Store.HasPendingExport(uid) {
+		log.Printf("[account-deletion] postponed publicId=%s: export in progress", pubID(app, uid))
+		return false
+	}
+	res, prof := app.Store.EraseAccount(uid, config.ErasureAnonymize())
+	for _, url := range res.Media {
+		removeUpload(app, url)
+	}
+	library := os.Remove(libraryPath(app, uid)) == nil
+	for _, path := range libraryBackups(app, uid) {
+		_ = os.Remove(path)
+	}
+	removeExports(app, app.Store.DropExports(time.Now().UTC(), uid))
+	saveAccountData(app)
+
+	sum := sha256.Sum256([]byte(uid))
+	rec := erasureAudit{
+		PublicID:      prof.PublicID,
+		SubjectHash:   hex.EncodeToString(sum[:]),
+		RequestedAt:   d.RequestedAt,
+		ConfirmedAt:   d.ConfirmedAt,
+		ErasedAt:      time.Now().UTC().Format(time.RFC3339),
+		ErasureResult: res,
+		Files:         len(res.Media),
+		Library:       library,
+	}
+	if err := appendErasureAudit(app.Paths.ErasureAuditFile, rec); err != nil {
+		log.Printf("[account-deletion] audit write failed publicId=%s: %v", prof.PublicID, err)
+	}
+	log.Printf("[account-deletion] erased publicId=%s posts=%d comments=%d likes=%d follows=%d conversations=%d messages=%d files=%d",
+		prof.PublicID, res.Posts, res.Comments, res.Likes, res.Follows, res.Conversations, res.Messages, len(res.Media))
+	return true
+}
+
+func appendErasureAudit(path string, rec erasureAudit) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
@@ -0,0 +1,208 @@
+package httpx
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"local.dev/socialdemo-backend/internal/store"
+)
+
+// ===== 連結身分與帳號合併 =====
+// 同一個人可能先用 email、後來用 Firebase UID（或反過來）登入而產生兩個帳號。
+// 登入時同時帶 email 與 UID 會自動連結（middleware.go 的 accountFor）；
+// 已經各自有資料的兩個帳號要明確合併：本人出示另一個帳號的登入憑證，或由管理員操作。
+
+// GET /me/identities → 連到目前帳號的識別碼
+func HandleMyIdentities(app *AppCtx) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		uid := currentUID(r)
+		writeJSON(w, http.StatusOK, map[string]any{
+			"account":    pubID(app, uid),
+			"identities": app.Store.ListIdentities(uid),
+		})
+	}
+}
+
+// POST /me/identities/merge  body: {"authorization":"Bearer <另一個帳號的 ID token>"}
+// 把憑證對應的帳號併進目前的帳號
+func HandleMyIdentitiesMerge(app *AppCtx) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		var in struct {
+			Authorization string `json:"authorization"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+			return
+		}
+		other := keyFromAuthorization(app, r, strings.TrimSpace(in.Authorization))
+		if other == "" {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid authorization for the other account"})
+			return
+		}
+		mergeAccounts(app, w, app.Store.CanonicalAccount(other), currentUID(r))
+	}
+}
+
+// POST /admin/users/merge  body: {"from":"u_xxx","into":"@handle"}
+func HandleAdminMergeUsers(app *AppCtx) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !isAdmin(app, r) {
+			writeJSON(w, http.StatusForbidden, map[string]string{"error": "admin only"})
+			return
+		}
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		var in struct {
+			From string `json:"from"`
+			Into string `json:"into"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+			return
+		}
+		from, ok := resolveUserOr404(app, w, r, in.From)
+		if !ok {
+			return
+		}
+		into, ok := resolveUserOr404(app, w, r, in.Into)
+		if !ok {
+			return
+		}
+		mergeAccounts(app, w, from, into)
+	}
+}
+
+func mergeAccounts(app *AppCtx, w http.ResponseWriter, from, into string) {
+	fromPub := pubID(app, from) // from 的 profile 合併後就不在了，先記下來
+	res, err := app.Store.MergeAccounts(from, into)
+	switch {
+	case errors.Is(err, store.ErrSameAccount):
+		writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+		return
+	case errors.Is(err, store.ErrAccountUnknown):
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		return
+	case err != nil:
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	// log 只寫 PublicID，不寫身分鍵（email / Firebase UID）
+	intoPub := pubID(app, res.Into)
+	if err := mergeLibrary(app, res.From, res.Into); err != nil {
+		log.Printf("[account-merge] library %s → %s: %v", fromPub, intoPub, errWithoutPath(err))
+	}
+	saveAccountData(app)
+	log.Printf("[account-merge] %s → %s posts=%d comments=%d likes=%d follows=%d boards=%d conversations=%d messages=%d exports=%d deletionCancelled=%t",
+		fromPub, intoPub, res.Posts, res.Comments, res.Likes, res.Follows, res.Boards, res.Conversations, res.Messages, res.Exports, res.DeletionCancelled)
+
+	res.From, res.Into = fromPub, intoPub
+	writeJSON(w, http.StatusOK, res)
+}
+
+// 合併 / 刪除帳號會動到幾乎所有資料集，全部存一次
+func saveAccountData(app *AppCtx) {
+	app.Store.SavePosts(app.Paths.PostsFile)
+	app.Store.SaveLikes(app.Paths.LikesFile)
+	app.Store.SaveTags(app.Paths.TagsFile)
+	app.Store.SaveFriends(app.Paths.FriendsFile)
+	app.Store.SaveFollowRequests(app.Paths.FollowRequestsFile)
+	app.Store.SaveProfiles(app.Paths.ProfilesFile)
+	app.Store.SaveBoards(app.Paths.BoardsFile)
+	app.Store.SaveConversations(app.Paths.ConversationsFile)
+	app.Store.SaveMessages(app.Paths.MessagesFile)
+	app.Store.SaveConversationSettings(app.Paths.ConversationSettingsFile)
+	app.Store.SaveBlocks(app.Paths.BlocksFile)
+	app.Store.SaveMutes(app.Paths.MutesFile)
+	app.Store.SaveIdentities(app.Paths.IdentitiesFile)
+	app.Store.SaveDeletions(app.Paths.DeletionsFile)
+	app.Store.SaveExports(app.Paths.ExportsFile)
+}
+
+// library_<from>.json 搬到 library_<into>.json。兩邊都有時 updated_at 比較新的那份當作 into 的 library，
+// 舊的那份不刪，留成 library_<into>.merged-<from>.json（payload 是用戶端格式，後端沒辦法逐筆合併）
+func mergeLibrary(app *AppCtx, from, into string) error {
+	src := libraryPath(app, from)
+	data, err := os.ReadFile(src)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var wrapped map[string]any
+	if err := json.Unmarshal(data, &wrapped); err != nil {
+		return err
+	}
+
+	dst := libraryPath(app, into)
+	kept := strings.TrimSuffix(dst, ".json") + ".merged-" + from + ".json"
+	if cur, err := os.ReadFile(dst); err == nil {
+		var existing map[string]any
+		if json.Unmarshal(cur, &existing) == nil && !libraryUpdatedAt(wrapped).After(libraryUpdatedAt(existing)) {
+			return os.Rename(src, kept) // into 的比較新：from 的留作備份
+		}
+		if err := os.Rename(dst, kept); err != nil { // from 的比較新：into 原本的留作備份
+			return err
+		}
+	}
+
+	wrapped["user_id"] = into
+	out, err := json.MarshalIndent(wrapped, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(dst, out, 0o644); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
+
+// 檔案錯誤的訊息裡有 library_<uid>.json 路徑，寫 log 前只留底層原因
+func errWithoutPath(err error) error {
+	var pe *fs.PathError
+	if errors.As(err, &pe) {
+		return pe.Err
+	}
+	var le *os.LinkError
+	if errors.As(err, &le) {
+		return le.Err
+	}
+	return err
+}
+
+// 合併時留下來的舊 library（library_<uid>.merged-*.json）
+func libraryBackups(app *AppCtx, uid string) []string {
+	prefix := "library_" + uid + ".merged-"
+	entries, _ := os.ReadDir(app.Paths.DataDir)
+	var out []string
+	for _, e := range entries {
+		if name := e.Name(); strings.HasPrefix(name, prefix) && strings.HasSuffix(name, ".json") {
+			out = append(out, filepath.Join(app.Paths.DataDir, name))
+		}
+	}
+	return out
+}
+
+func libraryUpdatedAt(wrapped map[string]any) time.Time {
+	s, _ := wrapped["updated_at"].(string)
+	t, _ := time.Parse(time.RFC3339, s)
+	return t
+}
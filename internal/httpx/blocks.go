@@ -0,0 +1,55 @@
+package httpx
+
+import (
+	"net/http"
+)
+
+// ===== 封鎖 =====
+
+// POST /users/{id}/block；DELETE /users/{id}/block
+func handleBlock(app *AppCtx, w http.ResponseWriter, r *http.Request, uid, target string) {
+	if target == uid {
+		http.Error(w, "cannot block yourself", http.StatusBadRequest)
+		return
+	}
+	switch r.Method {
+	case http.MethodPost:
+		if app.Store.Block(uid, target) {
+			app.Store.SaveFriends(app.Paths.FriendsFile) // 雙向的追蹤一併移除
+		}
+		app.Store.SaveFollowRequests(app.Paths.FollowRequestsFile)
+		app.Store.SaveBlocks(app.Paths.BlocksFile)
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodDelete:
+		app.Store.Unblock(uid, target)
+		app.Store.SaveBlocks(app.Paths.BlocksFile)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// GET /me/blocks → 自己封鎖的人
+func HandleMyBlocks(app *AppCtx) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		list := app.Store.ListBlocked(currentUID(r))
+		for i := range list {
+			list[i].UserID = pubID(app, list[i].UserID)
+		}
+		writeJSON(w, http.StatusOK, list)
+	}
+}
+
+// uid 跟 ids 裡任何一人有封鎖關係（不論哪一方封鎖）
+func blockedWithAny(app *AppCtx, uid string, ids []string) bool {
+	for _, id := range ids {
+		if id != uid && app.Store.IsBlockedEither(uid, id) {
+			return true
+		}
+	}
+	return false
+}
@@ -2,12 +2,15 @@ package httpx
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
+	"local.dev/socialdemo-backend/internal/config"
 	"local.dev/socialdemo-backend/internal/models"
+	"local.dev/socialdemo-backend/internal/store"
 )
 
 // GET /boards ；POST /boards
@@ -17,8 +20,13 @@ func HandleBoards(app *AppCtx) http.HandlerFunc {
 
 		switch r.Method {
 		case http.MethodGet:
+			// ?deleted=true → 自己已刪除、仍可復原的 boards
+			if r.URL.Query().Get("deleted") == "true" {
+				writeJSON(w, http.StatusOK, publicBoards(app, app.Store.ListDeletedBoardsFor(uid, config.BoardRestoreWindow())))
+				return
+			}
 			boards := app.Store.ListBoardsFor(uid)
-			writeJSON(w, http.StatusOK, boards)
+			writeJSON(w, http.StatusOK, publicBoards(app, boards))
 
 		case http.MethodPost:
 			var in struct {
@@ -45,7 +53,7 @@ func HandleBoards(app *AppCtx) http.HandlerFunc {
 				Description:  strings.TrimSpace(in.Description),
 				OwnerID:      uid,
 				ModeratorIDs: []string{},
-				IsOfficial:   false,
+				IsOfficial:   false, // 官方版只能由管理員建立（/admin/boards/official）
 				IsPrivate:    in.IsPrivate,
 				CreatedAt:    now,
 				UpdatedAt:    now,
@@ -56,7 +64,7 @@ func HandleBoards(app *AppCtx) http.HandlerFunc {
 			b = app.Store.SaveBoard(b)
 			app.Store.SaveBoards(app.Paths.BoardsFile)
 
-			writeJSON(w, http.StatusCreated, b)
+			writeJSON(w, http.StatusCreated, publicBoard(app, b))
 
 		default:
 			w.WriteHeader(http.StatusMethodNotAllowed)
@@ -81,7 +89,8 @@ func HandleBoardSub(app *AppCtx) http.HandlerFunc {
 			switch r.Method {
 			case http.MethodGet:
 				b, ok := app.Store.GetBoard(boardID)
-				if !ok || b.Deleted {
+				// 已刪除的 board 只有 owner 看得到（復原期限內）
+				if !ok || (b.Deleted && b.OwnerID != uid) {
 					writeJSON(w, http.StatusNotFound, map[string]string{"error": "board not found"})
 					return
 				}
@@ -90,7 +99,7 @@ func HandleBoardSub(app *AppCtx) http.HandlerFunc {
 					writeJSON(w, http.StatusForbidden, map[string]string{"error": "forbidden"})
 					return
 				}
-				writeJSON(w, http.StatusOK, b)
+				writeJSON(w, http.StatusOK, publicBoard(app, b))
 
 			case http.MethodPatch:
 				var in struct {
@@ -104,34 +113,77 @@ func HandleBoardSub(app *AppCtx) http.HandlerFunc {
 					return
 				}
 
-				b, ok := app.Store.GetBoard(boardID)
-				if !ok {
-					writeJSON(w, http.StatusNotFound, map[string]string{"error": "board not found"})
+				// 在 Store 的鎖內改：復原期限的檢查不會跟背景清除互相蓋掉
+				b, err := app.Store.UpdateBoard(boardID, func(b *models.Board) error {
+					if b.OwnerID != uid {
+						return errBoardNotOwner
+					}
+					if in.Name != nil {
+						b.Name = strings.TrimSpace(*in.Name)
+					}
+					if in.Description != nil {
+						b.Description = strings.TrimSpace(*in.Description)
+					}
+					if in.IsPrivate != nil {
+						// 官方版一律公開
+						if *in.IsPrivate && b.IsOfficial {
+							return errOfficialPrivate
+						}
+						b.IsPrivate = *in.IsPrivate
+					}
+					if in.Deleted != nil {
+						if *in.Deleted {
+							softDeleteBoard(b)
+						} else if b.Deleted && !restoreBoard(b) {
+							return errRestoreExpired
+						}
+					}
+					b.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+					return nil
+				})
+				switch {
+				case errors.Is(err, store.ErrBoardNotFound):
+					writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
 					return
-				}
-				if b.OwnerID != uid {
-					writeJSON(w, http.StatusForbidden, map[string]string{"error": "not owner"})
+				case errors.Is(err, errBoardNotOwner):
+					writeJSON(w, http.StatusForbidden, map[string]string{"error": err.Error()})
+					return
+				case errors.Is(err, errOfficialPrivate):
+					writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+					return
+				case errors.Is(err, errRestoreExpired):
+					writeJSON(w, http.StatusGone, map[string]string{"error": err.Error()})
 					return
 				}
+				app.Store.SaveBoards(app.Paths.BoardsFile)
 
-				if in.Name != nil {
-					b.Name = strings.TrimSpace(*in.Name)
-				}
-				if in.Description != nil {
-					b.Description = strings.TrimSpace(*in.Description)
-				}
-				if in.IsPrivate != nil {
-					b.IsPrivate = *in.IsPrivate
-				}
-				if in.Deleted != nil {
-					b.Deleted = *in.Deleted
-				}
-				b.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+				writeJSON(w, http.StatusOK, publicBoard(app, b))
 
-				app.Store.SaveBoard(b)
+			case http.MethodDelete:
+				// 等同 PATCH {"deleted": true}：軟刪除，期限內可復原
+				// 跟 PATCH 一樣在 Store 的鎖內改，不會蓋掉同時進來的修改或復原
+				b, err := app.Store.UpdateBoard(boardID, func(b *models.Board) error {
+					if b.Deleted {
+						return store.ErrBoardNotFound
+					}
+					if b.OwnerID != uid {
+						return errBoardNotOwner
+					}
+					softDeleteBoard(b)
+					b.UpdatedAt = b.DeletedAt
+					return nil
+				})
+				switch {
+				case errors.Is(err, store.ErrBoardNotFound):
+					writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+					return
+				case errors.Is(err, errBoardNotOwner):
+					writeJSON(w, http.StatusForbidden, map[string]string{"error": err.Error()})
+					return
+				}
 				app.Store.SaveBoards(app.Paths.BoardsFile)
 
-				writeJSON(w, http.StatusOK, b)
+				writeJSON(w, http.StatusOK, publicBoard(app, b))
 
 			default:
 				w.WriteHeader(http.StatusMethodNotAllowed)
@@ -203,6 +255,7 @@ func HandleBoardSub(app *AppCtx) http.HandlerFunc {
 				posts = posts[:limit]
 			}
 
+			hydratePostAuthors(app, posts)
 			writeJSON(w, http.StatusOK, posts)
 			return
 		}
@@ -210,3 +263,31 @@ func HandleBoardSub(app *AppCtx) http.HandlerFunc {
 		http.NotFound(w, r)
 	}
 }
+
+var (
+	errBoardNotOwner   = errors.New("not owner")
+	errOfficialPrivate = errors.New("official board cannot be private")
+	errRestoreExpired  = errors.New("restore window expired")
+)
+
+// 軟刪除：標記 deleted 並記下可復原的期限（已刪除過就保留原本的期限）
+func softDeleteBoard(b *models.Board) {
+	if b.Deleted {
+		return
+	}
+	now := time.Now().UTC()
+	b.Deleted = true
+	b.DeletedAt = now.Format(time.RFC3339)
+	b.PurgeAt = now.Add(config.BoardRestoreWindow()).Format(time.RFC3339)
+}
+
+// 復原：超過期限（store.BoardPurgeDeadline）回傳 false。要在 Store.UpdateBoard 裡呼叫
+func restoreBoard(b *models.Board) bool {
+	if !time.Now().UTC().Before(store.BoardPurgeDeadline(*b, config.BoardRestoreWindow())) {
+		return false
+	}
+	b.Deleted = false
+	b.DeletedAt = ""
+	b.PurgeAt = ""
+	return true
+}
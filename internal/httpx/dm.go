@@ -2,12 +2,16 @@ package httpx
 
 import (
 	"encoding/json"
+	"io"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
 	"local.dev/socialdemo-backend/internal/models"
+	"local.dev/socialdemo-backend/internal/msgtypes"
+	"local.dev/socialdemo-backend/internal/realtime"
+	"local.dev/socialdemo-backend/internal/store"
 )
 
 // GET /conversations ；POST /conversations
@@ -17,19 +21,39 @@ func HandleConversations(app *AppCtx) http.HandlerFunc {
 
 		switch r.Method {
 		case http.MethodGet:
-			convs := app.Store.ListConversationsFor(uid)
-			// unreadCount 目前沒有做，就讓前端自己預設 0
+			// 已依 viewer 算好 unreadCount / settings，置頂的排前面
+			// 預設不含封存的對話與訊息請求；?archived=true 只列封存的、?requests=true 只列訊息請求
+			archived := r.URL.Query().Get("archived") == "true"
+			requests := r.URL.Query().Get("requests") == "true"
+			all := app.Store.ListConversationsFor(uid)
+			convs := make([]models.Conversation, 0, len(all))
+			for _, c := range all {
+				if containsString(c.DeclinedBy, uid) {
+					continue
+				}
+				c = viewConversation(c, uid)
+				if c.IsRequest != requests || (!requests && c.Settings.Archived != archived) {
+					continue
+				}
+				convs = append(convs, publicConversation(app, localizePreview(app, r, uid, c)))
+			}
 			writeJSON(w, http.StatusOK, convs)
 
 		case http.MethodPost:
 			var in struct {
 				MemberIDs []string `json:"memberIds"`
 				Name      string   `json:"name"`
+				Kind      string   `json:"kind"` // "direct" / "group"；省略時兩人以下視為 direct
 			}
 			if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
 				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
 				return
 			}
+			ids, ok := resolveUsersOr404(app, w, r, in.MemberIDs) // 接受 PublicID / @handle
+			if !ok {
+				return
+			}
+			in.MemberIDs = ids
 			if len(in.MemberIDs) == 0 {
 				in.MemberIDs = []string{uid}
 			}
@@ -62,21 +86,62 @@ func HandleConversations(app *AppCtx) http.HandlerFunc {
 				members = append(members, m)
 			}
 
+			// 封鎖關係（任一方）不能私訊
+			if blockedWithAny(app, uid, members) {
+				writeJSON(w, http.StatusForbidden, map[string]string{"error": "cannot message this user"})
+				return
+			}
+
+			kind := strings.ToLower(strings.TrimSpace(in.Kind))
+			if kind == "" {
+				kind = store.ConversationGroup
+				if len(members) <= 2 {
+					kind = store.ConversationDirect
+				}
+			}
+			if kind != store.ConversationDirect && kind != store.ConversationGroup {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid kind"})
+				return
+			}
+			if kind == store.ConversationDirect && len(members) > 2 {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "direct conversation takes exactly one other member"})
+				return
+			}
+
 			c := models.Conversation{
 				// ⭐ 這裡 ID 先給空字串
 				ID:                 "",
+				Kind:               kind,
 				Name:               strings.TrimSpace(in.Name),
 				MemberIDs:          members,
+				PendingFor:         pendingMembers(app, uid, members), // 沒追蹤我的人先進「請求」收件匣
+				CreatedBy:          uid,
 				CreatedAt:          now,
 				LastMessageAt:      "",
 				LastMessagePreview: "",
 			}
 
-			// ⭐ 交給 Store 補 ID
+			// 一對一：同一組人已經有對話就回傳既有的（200），不再重複建立
+			if kind == store.ConversationDirect {
+				c, created := app.Store.GetOrCreateDirectConversation(c)
+				if !created {
+					// 自己主動找對方 → 原本的請求 / 拒絕一併視為接受
+					c = acceptRequest(app, c.ID, uid)
+					c.UnreadCount = app.Store.UnreadCount(c.ID, uid)
+					writeJSON(w, http.StatusOK, publicConversation(app, viewConversation(c, uid)))
+					return
+				}
+				app.Store.SaveConversations(app.Paths.ConversationsFile)
+				writeJSON(w, http.StatusCreated, publicConversation(app, viewConversation(c, uid)))
+				return
+			}
+
+			// ⭐ 群組：每次都是新的對話，建立者為管理員，交給 Store 補 ID
+			c.AdminIDs = []string{uid}
 			c = app.Store.SaveConversation(c)
 			app.Store.SaveConversations(app.Paths.ConversationsFile)
 
-			writeJSON(w, http.StatusCreated, c)
+			writeJSON(w, http.StatusCreated, publicConversation(app, viewConversation(c, uid)))
 
 		default:
 			w.WriteHeader(http.StatusMethodNotAllowed)
@@ -84,9 +149,27 @@ func HandleConversations(app *AppCtx) http.HandlerFunc {
 	}
 }
 
-// GET /conversations/{id}/messages
-// POST /conversations/{id}/messages
-func HandleConversationMessages(app *AppCtx) http.HandlerFunc {
+// GET    /conversations/{id}                          → 對話資訊
+// PATCH  /conversations/{id}                          → 群組改名
+// GET    /conversations/{id}/messages
+// POST   /conversations/{id}/messages
+// GET    /conversations/{id}/messages/search?q=       → 對話內搜尋
+// PATCH  /conversations/{id}/messages/{mid}           → 編輯（限時）
+// DELETE /conversations/{id}/messages/{mid}           → 收回（對所有人）
+// DELETE /conversations/{id}/messages/{mid}?for=me    → 只對自己刪除
+// POST   /conversations/{id}/members                  → 群組加人
+// DELETE /conversations/{id}/members/{uid}            → 群組踢人（踢自己 = 退出）
+// POST   /conversations/{id}/leave                    → 退出群組
+// POST   /conversations/{id}/admins                   → 指派群組管理員
+// DELETE /conversations/{id}/admins/{uid}             → 取消群組管理員
+// POST   /conversations/{id}/read
+// POST   /conversations/{id}/typing
+// GET    /conversations/{id}/presence
+// GET    /conversations/{id}/settings                 → 自己的靜音 / 封存 / 置頂 / 暱稱
+// PATCH  /conversations/{id}/settings
+// POST   /conversations/{id}/accept                   → 接受訊息請求
+// POST   /conversations/{id}/decline                  → 拒絕訊息請求
+func HandleConversationSub(app *AppCtx) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		uid := currentUID(r)
 		path := strings.TrimPrefix(r.URL.Path, "/conversations/")
@@ -95,56 +178,215 @@ func HandleConversationMessages(app *AppCtx) http.HandlerFunc {
 			return
 		}
 		parts := strings.Split(path, "/")
-		if len(parts) != 2 || parts[1] != "messages" {
-			http.NotFound(w, r)
-			return
-		}
 		convID := parts[0]
 
-		switch r.Method {
-		case http.MethodGet:
-			handleFetchMessages(app, w, r, uid, convID)
-		case http.MethodPost:
-			handleSendMessage(app, w, r, uid, convID)
+		// 允許的 method 不符就回 405
+		allow := func(method string) bool {
+			if r.Method != method {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return false
+			}
+			return true
+		}
+
+		switch {
+		case len(parts) == 1:
+			switch r.Method {
+			case http.MethodGet:
+				handleGetConversation(app, w, r, uid, convID)
+			case http.MethodPatch:
+				handleRenameConversation(app, w, r, uid, convID)
+			default:
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+
+		case len(parts) == 3 && parts[2] != "":
+			switch parts[1] {
+			case "messages":
+				if parts[2] == "search" {
+					if allow(http.MethodGet) {
+						handleSearchConversation(app, w, r, uid, convID)
+					}
+					return
+				}
+				switch r.Method {
+				case http.MethodPatch:
+					handleEditMessage(app, w, r, uid, convID, parts[2])
+				case http.MethodDelete:
+					handleDeleteMessage(app, w, r, uid, convID, parts[2])
+				default:
+					w.WriteHeader(http.StatusMethodNotAllowed)
+				}
+			case "members":
+				if allow(http.MethodDelete) {
+					if target, ok := resolveUserOr404(app, w, r, parts[2]); ok {
+						handleRemoveMember(app, w, r, uid, convID, target)
+					}
+				}
+			case "admins":
+				if allow(http.MethodDelete) {
+					if target, ok := resolveUserOr404(app, w, r, parts[2]); ok {
+						handleSetAdmin(app, w, r, uid, convID, target, false)
+					}
+				}
+			default:
+				http.NotFound(w, r)
+			}
+
+		case len(parts) == 2:
+			switch parts[1] {
+			case "messages":
+				switch r.Method {
+				case http.MethodGet:
+					handleFetchMessages(app, w, r, uid, convID)
+				case http.MethodPost:
+					handleSendMessage(app, w, r, uid, convID)
+				default:
+					w.WriteHeader(http.StatusMethodNotAllowed)
+				}
+			case "members":
+				if allow(http.MethodPost) {
+					handleAddMembers(app, w, r, uid, convID)
+				}
+			case "leave":
+				if allow(http.MethodPost) {
+					handleRemoveMember(app, w, r, uid, convID, uid)
+				}
+			case "admins":
+				if allow(http.MethodPost) {
+					var in struct {
+						UserID string `json:"userId"`
+					}
+					if err := json.NewDecoder(r.Body).Decode(&in); err != nil || strings.TrimSpace(in.UserID) == "" {
+						writeJSON(w, http.StatusBadRequest, map[string]string{"error": "userId is required"})
+						return
+					}
+					if target, ok := resolveUserOr404(app, w, r, in.UserID); ok {
+						handleSetAdmin(app, w, r, uid, convID, target, true)
+					}
+				}
+			case "read":
+				if allow(http.MethodPost) {
+					handleMarkRead(app, w, r, uid, convID)
+				}
+			case "typing":
+				if allow(http.MethodPost) {
+					handleTyping(app, w, r, uid, convID)
+				}
+			case "presence":
+				if allow(http.MethodGet) {
+					handleConversationPresence(app, w, r, uid, convID)
+				}
+			case "settings":
+				handleConversationSettings(app, w, r, uid, convID)
+			case "accept":
+				if allow(http.MethodPost) {
+					handleAcceptRequest(app, w, r, uid, convID)
+				}
+			case "decline":
+				if allow(http.MethodPost) {
+					handleDeclineRequest(app, w, r, uid, convID)
+				}
+			default:
+				http.NotFound(w, r)
+			}
+
 		default:
-			w.WriteHeader(http.StatusMethodNotAllowed)
+			http.NotFound(w, r)
 		}
 	}
 }
 
+// GET /conversations/{id}/messages?before=&after=&limit=
+// 預設回傳最新 limit 則（由舊到新排列）；用第一則的 id 當 before 往前翻，hasMore=false 代表到頂了
 func handleFetchMessages(app *AppCtx, w http.ResponseWriter, r *http.Request, uid, convID string) {
 	q := r.URL.Query()
-	afterStr := q.Get("after")
-	beforeStr := q.Get("before")
-	limitStr := q.Get("limit")
-
-	var after, before time.Time
-	if afterStr != "" {
-		if t, err := time.Parse(time.RFC3339, afterStr); err == nil {
-			after = t.UTC()
-		}
+
+	// 確認會議存在且自己在成員裡；已離開的成員只能讀到離開當下為止
+	conv, ok := app.Store.GetConversation(convID)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "conversation not found"})
+		return
+	}
+	former, wasMember := conv.FormerMembers[uid]
+	isMember := containsString(conv.MemberIDs, uid)
+	if !isMember && !wasMember {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "conversation not found"})
+		return
+	}
+
+	// before / after：訊息 ID 或 RFC3339 時間
+	var query store.MessageQuery
+	before, ok1 := app.Store.MessageCursor(convID, q.Get("before"))
+	after, ok2 := app.Store.MessageCursor(convID, q.Get("after"))
+	if !ok1 || !ok2 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid cursor"})
+		return
+	}
+	query.Before, query.After = before, after
+	if n, err := strconv.Atoi(q.Get("limit")); err == nil && n > 0 {
+		query.Limit = n
 	}
-	if beforeStr != "" {
-		if t, err := time.Parse(time.RFC3339, beforeStr); err == nil {
-			before = t.UTC()
+	if !isMember {
+		query.Until = models.ReadCursor{MessageID: former.LastMessageID, MessageAt: former.LeftAt}
+	}
+
+	page := app.Store.ListMessages(convID, uid, query)
+
+	// 群組聊天：附上每則訊息的已讀名單（還沒接受請求的成員不列）
+	if conv.Kind == store.ConversationGroup {
+		visible := viewConversation(conv, uid)
+		for i := range page.Messages {
+			page.Messages[i].SeenBy = store.SeenBy(visible, page.Messages[i])
 		}
 	}
-	limit := 0
-	if limitStr != "" {
-		if n, err := strconv.Atoi(limitStr); err == nil && n > 0 {
-			limit = n
+	page.Messages = publicMessages(app, page.Messages)
+	writeJSON(w, http.StatusOK, page)
+}
+
+// POST /conversations/{id}/read  body（可省略）：{"messageId": "m_xxx"}
+// 沒帶 messageId 就視為讀到最新一則
+func handleMarkRead(app *AppCtx, w http.ResponseWriter, r *http.Request, uid, convID string) {
+	var in struct {
+		MessageID string `json:"messageId"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil && err != io.EOF {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+			return
 		}
 	}
 
-	// 確認會議存在且自己在成員裡
 	conv, ok := app.Store.GetConversation(convID)
 	if !ok || !containsString(conv.MemberIDs, uid) {
 		writeJSON(w, http.StatusNotFound, map[string]string{"error": "conversation not found"})
 		return
 	}
 
-	msgs := app.Store.ListMessages(convID, after, before, limit)
-	writeJSON(w, http.StatusOK, msgs)
+	cur, ok := app.Store.MarkRead(convID, uid, strings.TrimSpace(in.MessageID))
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "message not found"})
+		return
+	}
+	app.Store.SaveConversations(app.Paths.ConversationsFile)
+
+	ev := realtime.Event{
+		Type:           realtime.EventRead,
+		ConversationID: convID,
+		UserID:         uid,
+		Data:           cur,
+	}
+	if readReceiptsHidden(conv, uid) {
+		app.Hub.Publish(uid, publicEvent(app, ev)) // 還沒接受 / 已拒絕請求：已讀只同步自己的裝置
+	} else {
+		publishToMembers(app, conv, ev)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"conversationId": convID,
+		"userId":         pubID(app, uid),
+		"cursor":         cur,
+	})
 }
 
 func handleSendMessage(app *AppCtx, w http.ResponseWriter, r *http.Request, uid, convID string) {
@@ -158,9 +400,6 @@ func handleSendMessage(app *AppCtx, w http.ResponseWriter, r *http.Request, uid,
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
 		return
 	}
-	if in.Type == "" {
-		in.Type = "text"
-	}
 
 	conv, ok := app.Store.GetConversation(convID)
 	if !ok || !containsString(conv.MemberIDs, uid) {
@@ -168,26 +407,187 @@ func handleSendMessage(app *AppCtx, w http.ResponseWriter, r *http.Request, uid,
 		return
 	}
 
+	// 依訊息種類登錄表驗證 + 正規化（未知欄位會被丟掉）
+	body, err := msgtypes.Normalize(in.Type, in.ContentSchema, in.Text, in.ContentJSON)
+	if err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	// 一對一：跟對方有封鎖關係就不能再傳
+	if conv.Kind == store.ConversationDirect && blockedWithAny(app, uid, conv.MemberIDs) {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "cannot message this user"})
+		return
+	}
+
+	// 訊息請求：收件者回覆視同接受；發起者在對方接受前只能送一則
+	conv = acceptRequest(app, convID, uid)
+	if requestSendLimited(app, conv, uid) {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "message request not accepted yet"})
+		return
+	}
+
 	now := time.Now().UTC().Format(time.RFC3339)
 
 	m := models.Message{
 		ID:             "", // ⭐ 讓 Store 自己 newID("m")
 		ConversationID: convID,
 		SenderID:       uid,
-		Type:           in.Type,
-		Text:           in.Text,
-		ContentSchema:  in.ContentSchema,
-		ContentJson:    in.ContentJSON,
+		Type:           body.Type,
+		Text:           body.Text,
+		ContentSchema:  body.ContentSchema,
+		ContentJson:    body.ContentJson,
 		CreatedAt:      now,
 		Deleted:        false,
 	}
 
 	m = app.Store.SaveMessage(m)
+	app.Store.MarkRead(convID, uid, m.ID) // 自己傳的訊息視為已讀
+	app.Hub.SetTyping(convID, uid, false) // 送出即結束「輸入中」
+	app.Store.SaveMessages(app.Paths.MessagesFile)
+	app.Store.SaveConversations(app.Paths.ConversationsFile)
+
+	publishToMembers(app, conv, realtime.Event{
+		Type:   realtime.EventMessageNew,
+		UserID: uid,
+		Data:   m,
+	})
+
+	writeJSON(w, http.StatusCreated, publicMessage(app, m))
+
+}
+
+// 訊息送出後可編輯的時間
+const messageEditWindow = 15 * time.Minute
+
+// PATCH /conversations/{id}/messages/{mid}  body: {"text": "..."}
+func handleEditMessage(app *AppCtx, w http.ResponseWriter, r *http.Request, uid, convID, msgID string) {
+	var in struct {
+		Text string `json:"text"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+	body, err := msgtypes.Normalize("text", "", in.Text, nil)
+	if err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	conv, ok := app.Store.GetConversation(convID)
+	if !ok || !containsString(conv.MemberIDs, uid) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "conversation not found"})
+		return
+	}
+
+	m, err := app.Store.EditMessage(convID, msgID, uid, body.Text, messageEditWindow)
+	if err != nil {
+		writeMessageError(w, err)
+		return
+	}
+	app.Store.SaveMessages(app.Paths.MessagesFile)
+	app.Store.SaveConversations(app.Paths.ConversationsFile)
+
+	m.HiddenFor = nil
+	publishToMembers(app, conv, realtime.Event{
+		Type:   realtime.EventMessageEdit,
+		UserID: uid,
+		Data:   m,
+	})
+	writeJSON(w, http.StatusOK, publicMessage(app, m))
+}
+
+// DELETE /conversations/{id}/messages/{mid}[?for=me]
+func handleDeleteMessage(app *AppCtx, w http.ResponseWriter, r *http.Request, uid, convID, msgID string) {
+	conv, ok := app.Store.GetConversation(convID)
+	if !ok || !containsString(conv.MemberIDs, uid) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "conversation not found"})
+		return
+	}
+
+	// 只對自己刪除：只通知自己的其他裝置
+	if r.URL.Query().Get("for") == "me" {
+		if err := app.Store.HideMessage(convID, msgID, uid); err != nil {
+			writeMessageError(w, err)
+			return
+		}
+		app.Store.SaveMessages(app.Paths.MessagesFile)
+		app.Hub.Publish(uid, publicEvent(app, realtime.Event{
+			Type:           realtime.EventMessageDelete,
+			ConversationID: convID,
+			UserID:         uid,
+			Data:           map[string]string{"messageId": msgID, "scope": "me"},
+		}))
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	m, err := app.Store.UnsendMessage(convID, msgID, uid)
+	if err != nil {
+		writeMessageError(w, err)
+		return
+	}
 	app.Store.SaveMessages(app.Paths.MessagesFile)
 	app.Store.SaveConversations(app.Paths.ConversationsFile)
 
-	writeJSON(w, http.StatusCreated, m)
+	m.HiddenFor = nil
+	publishToMembers(app, conv, realtime.Event{
+		Type:   realtime.EventMessageDelete,
+		UserID: uid,
+		Data:   map[string]string{"messageId": msgID, "scope": "everyone"},
+	})
+	writeJSON(w, http.StatusOK, publicMessage(app, m))
+}
+
+func writeMessageError(w http.ResponseWriter, err error) {
+	switch err {
+	case store.ErrMessageNotFound:
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+	case store.ErrNotSender:
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": err.Error()})
+	case store.ErrEditWindow, store.ErrNotEditable:
+		writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+	default:
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+}
+
+// 訊息內容驗證失敗 → 422，逐欄列出原因
+func writeValidationError(w http.ResponseWriter, err error) {
+	if verr, ok := err.(*msgtypes.ValidationError); ok {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]any{
+			"error":  "invalid message",
+			"fields": verr.Fields,
+		})
+		return
+	}
+	writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+}
 
+// 存檔的預覽是 DefaultLocale、而且是所有成員共用的；依 viewer 重新產生：
+// 最後一則被 viewer 自己隱藏時改用他看得到的最新一則，再依語系（?locale= / Accept-Language）產生預覽。
+// lastMessageAt 仍是對話的最後活動時間，列表排序不受影響
+func localizePreview(app *AppCtx, r *http.Request, uid string, c models.Conversation) models.Conversation {
+	if c.LastMessageID == "" {
+		return c
+	}
+	m, ok := app.Store.GetMessage(c.LastMessageID)
+	if !ok {
+		return c
+	}
+	locale := pickLocale(r.URL.Query().Get("locale"), r.Header.Get("Accept-Language"))
+	if containsString(m.HiddenFor, uid) {
+		m = app.Store.LatestMessageFor(c.ID, uid)
+		c.LastMessageID, c.LastMessagePreview = m.ID, ""
+		if m.ID == "" {
+			return c
+		}
+	} else if locale == store.DefaultLocale {
+		return c
+	}
+	c.LastMessagePreview = msgtypes.Preview(m, locale)
+	return c
 }
 
 func containsString(list []string, v string) bool {
@@ -0,0 +1,247 @@
+package httpx
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"local.dev/socialdemo-backend/internal/models"
+	"local.dev/socialdemo-backend/internal/realtime"
+	"local.dev/socialdemo-backend/internal/store"
+)
+
+// ===== 群組管理：改名 / 加人 / 踢人 / 退出 / 管理員 =====
+// 每次異動都會在時間軸插入一則系統訊息（Type: "system"），並推送 conversation.updated
+
+// 舊資料沒有 adminIds 時，所有成員都視為管理員
+func isGroupAdmin(c models.Conversation, uid string) bool {
+	if !containsString(c.MemberIDs, uid) {
+		return false
+	}
+	return len(c.AdminIDs) == 0 || containsString(c.AdminIDs, uid)
+}
+
+// 取出群組並檢查自己是成員；失敗時已寫好回應
+func loadGroup(app *AppCtx, w http.ResponseWriter, uid, convID string) (models.Conversation, bool) {
+	conv, ok := app.Store.GetConversation(convID)
+	if !ok || !containsString(conv.MemberIDs, uid) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "conversation not found"})
+		return conv, false
+	}
+	if conv.Kind != store.ConversationGroup {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "not a group conversation"})
+		return conv, false
+	}
+	return conv, true
+}
+
+func withoutString(list []string, v string) []string {
+	out := make([]string, 0, len(list))
+	for _, x := range list {
+		if x != v {
+			out = append(out, x)
+		}
+	}
+	return out
+}
+
+// 插入系統訊息並推送給目前成員
+func appendSystemMessage(app *AppCtx, conv models.Conversation, actor, event string, targets []string, extra map[string]any) models.Message {
+	content := map[string]any{
+		"event":     event,
+		"actorId":   actor,
+		"targetIds": targets,
+	}
+	for k, v := range extra {
+		content[k] = v
+	}
+	m := app.Store.SaveMessage(models.Message{
+		ConversationID: conv.ID,
+		SenderID:       actor,
+		Type:           "system",
+		ContentSchema:  "system.v1",
+		ContentJson:    content,
+		CreatedAt:      time.Now().UTC().Format(time.RFC3339),
+	})
+	app.Store.SaveMessages(app.Paths.MessagesFile)
+	publishToMembers(app, conv, realtime.Event{
+		Type:   realtime.EventMessageNew,
+		UserID: actor,
+		Data:   m,
+	})
+	return m
+}
+
+// Store 已經改好對話之後：存檔並通知。extra 是「已經不在成員名單、但也要收到這次更新」的人（被踢 / 退出的人）
+func saveGroup(app *AppCtx, conv models.Conversation, actor string, extra ...string) models.Conversation {
+	app.Store.SaveConversations(app.Paths.ConversationsFile)
+	ev := realtime.Event{
+		Type:           realtime.EventConversationUpdated,
+		ConversationID: conv.ID,
+		UserID:         actor,
+		Data:           viewConversation(conv, ""),
+	}
+	ev = publicEvent(app, ev)
+	for _, id := range conv.MemberIDs {
+		if !containsString(conv.DeclinedBy, id) {
+			app.Hub.Publish(id, ev)
+		}
+	}
+	app.Hub.PublishMany(extra, ev)
+	return conv
+}
+
+// GET /conversations/{id}
+func handleGetConversation(app *AppCtx, w http.ResponseWriter, r *http.Request, uid, convID string) {
+	conv, ok := app.Store.GetConversation(convID)
+	_, former := conv.FormerMembers[uid]
+	if !ok || (!containsString(conv.MemberIDs, uid) && !former) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "conversation not found"})
+		return
+	}
+	conv.UnreadCount = app.Store.UnreadCount(convID, uid)
+	st := app.Store.GetConversationSettings(uid, convID)
+	conv.Settings = &st
+	writeJSON(w, http.StatusOK, publicConversation(app, localizePreview(app, r, uid, viewConversation(conv, uid))))
+}
+
+// PATCH /conversations/{id}  body: {"name": "..."}
+func handleRenameConversation(app *AppCtx, w http.ResponseWriter, r *http.Request, uid, convID string) {
+	var in struct {
+		Name *string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil || in.Name == nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "name is required"})
+		return
+	}
+	conv, ok := loadGroup(app, w, uid, convID)
+	if !ok {
+		return
+	}
+	if !isGroupAdmin(conv, uid) {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "group admin only"})
+		return
+	}
+	name := strings.TrimSpace(*in.Name)
+	conv, changed := app.Store.RenameConversation(convID, name)
+	if !changed {
+		writeJSON(w, http.StatusOK, publicConversation(app, viewConversation(conv, uid)))
+		return
+	}
+	conv = saveGroup(app, conv, uid)
+	appendSystemMessage(app, conv, uid, "renamed", []string{}, map[string]any{"name": name})
+
+	conv, _ = app.Store.GetConversation(convID)
+	writeJSON(w, http.StatusOK, publicConversation(app, viewConversation(conv, uid)))
+}
+
+// POST /conversations/{id}/members  body: {"memberIds": ["..."]}
+func handleAddMembers(app *AppCtx, w http.ResponseWriter, r *http.Request, uid, convID string) {
+	var in struct {
+		MemberIDs []string `json:"memberIds"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+	ids, ok := resolveUsersOr404(app, w, r, in.MemberIDs)
+	if !ok {
+		return
+	}
+	in.MemberIDs = ids
+	conv, ok := loadGroup(app, w, uid, convID)
+	if !ok {
+		return
+	}
+	if !isGroupAdmin(conv, uid) {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "group admin only"})
+		return
+	}
+
+	if blockedWithAny(app, uid, in.MemberIDs) {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "cannot add this user"})
+		return
+	}
+
+	conv, added := app.Store.AddConversationMembers(convID, in.MemberIDs, pendingMembers(app, uid, in.MemberIDs))
+	if len(added) == 0 {
+		writeJSON(w, http.StatusOK, publicConversation(app, viewConversation(conv, uid)))
+		return
+	}
+	conv = saveGroup(app, conv, uid)
+	appendSystemMessage(app, conv, uid, "member_added", added, nil)
+
+	conv, _ = app.Store.GetConversation(convID)
+	writeJSON(w, http.StatusOK, publicConversation(app, viewConversation(conv, uid)))
+}
+
+// DELETE /conversations/{id}/members/{uid}；POST /conversations/{id}/leave（target = 自己）
+func handleRemoveMember(app *AppCtx, w http.ResponseWriter, _ *http.Request, uid, convID, target string) {
+	conv, ok := loadGroup(app, w, uid, convID)
+	if !ok {
+		return
+	}
+	leaving := target == uid
+	if !leaving && !isGroupAdmin(conv, uid) {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "group admin only"})
+		return
+	}
+	if !containsString(conv.MemberIDs, target) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "member not found"})
+		return
+	}
+
+	// 系統訊息先插入（離開的人也看得到自己離開的那一則），再把人移出
+	event := "member_removed"
+	if leaving {
+		event = "member_left"
+	}
+	sys := appendSystemMessage(app, conv, uid, event, []string{target}, nil)
+	conv, ok = app.Store.RemoveConversationMember(convID, target, models.FormerMember{LeftAt: sys.CreatedAt, LastMessageID: sys.ID})
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "member not found"})
+		return
+	}
+	conv = saveGroup(app, conv, uid, target)
+	if leaving {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writeJSON(w, http.StatusOK, publicConversation(app, viewConversation(conv, uid)))
+}
+
+// POST /conversations/{id}/admins  body: {"userId": "..."}；DELETE /conversations/{id}/admins/{uid}
+func handleSetAdmin(app *AppCtx, w http.ResponseWriter, _ *http.Request, uid, convID, target string, add bool) {
+	conv, ok := loadGroup(app, w, uid, convID)
+	if !ok {
+		return
+	}
+	if !isGroupAdmin(conv, uid) {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "group admin only"})
+		return
+	}
+	if !containsString(conv.MemberIDs, target) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "member not found"})
+		return
+	}
+
+	conv, changed, err := app.Store.SetConversationAdmin(convID, uid, target, add)
+	if err != nil {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+		return
+	}
+	if !changed {
+		writeJSON(w, http.StatusOK, publicConversation(app, viewConversation(conv, uid)))
+		return
+	}
+	event := "admin_added"
+	if !add {
+		event = "admin_removed"
+	}
+	conv = saveGroup(app, conv, uid)
+	appendSystemMessage(app, conv, uid, event, []string{target}, nil)
+
+	conv, _ = app.Store.GetConversation(convID)
+	writeJSON(w, http.StatusOK, publicConversation(app, viewConversation(conv, uid)))
+}
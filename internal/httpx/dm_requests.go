@@ -0,0 +1,117 @@
+package httpx
+
+import (
+	"net/http"
+
+	"local.dev/socialdemo-backend/internal/models"
+	"local.dev/socialdemo-backend/internal/realtime"
+	"local.dev/socialdemo-backend/internal/store"
+)
+
+// ===== DM：訊息請求 =====
+// 被自己沒追蹤（Store.friends）的人拉進對話時，對話先放在「請求」收件匣：
+//   - 接受前，其他人看不到他的已讀游標 / seenBy
+//   - 一對一對話在對方接受前，發起者只能送一則訊息
+//   - 收件者回覆訊息視同接受；拒絕後對話不再出現在他的列表
+
+// 新成員裡沒追蹤 inviter 的人 → 需要先接受
+func pendingMembers(app *AppCtx, inviter string, members []string) []string {
+	out := make([]string, 0)
+	for _, m := range members {
+		if m != inviter && !app.Store.IsFollowing(m, inviter) {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// 還沒接受或已拒絕請求的成員：已讀狀態不讓其他人看到
+func readReceiptsHidden(c models.Conversation, uid string) bool {
+	return containsString(c.PendingFor, uid) || containsString(c.DeclinedBy, uid)
+}
+
+// 依 viewer 整理回應：標出是不是請求、藏起還沒接受（或已拒絕）的成員的已讀游標、拒絕名單不外流
+func viewConversation(c models.Conversation, viewer string) models.Conversation {
+	c.IsRequest = containsString(c.PendingFor, viewer)
+	if len(c.PendingFor)+len(c.DeclinedBy) > 0 && len(c.ReadCursors) > 0 {
+		cursors := make(map[string]models.ReadCursor, len(c.ReadCursors))
+		for id, cur := range c.ReadCursors {
+			if id == viewer || !readReceiptsHidden(c, id) {
+				cursors[id] = cur
+			}
+		}
+		c.ReadCursors = cursors
+	}
+	c.DeclinedBy = nil
+	return c
+}
+
+// 一對一請求還沒被接受（或已被拒絕）時，發起者只能送一則
+func requestSendLimited(app *AppCtx, c models.Conversation, uid string) bool {
+	if c.Kind != store.ConversationDirect {
+		return false
+	}
+	waiting := false
+	for _, id := range append(append([]string(nil), c.PendingFor...), c.DeclinedBy...) {
+		if id != uid {
+			waiting = true
+		}
+	}
+	return waiting && app.Store.CountMessagesFrom(c.ID, uid) >= 1
+}
+
+// 把 uid 從請求 / 拒絕名單拿掉；有變動才存檔並通知。回傳的是最新的對話
+func acceptRequest(app *AppCtx, convID, uid string) models.Conversation {
+	c, changed := app.Store.AcceptConversationRequest(convID, uid)
+	if !changed {
+		return c
+	}
+	return saveGroup(app, c, uid)
+}
+
+// POST /conversations/{id}/accept
+func handleAcceptRequest(app *AppCtx, w http.ResponseWriter, _ *http.Request, uid, convID string) {
+	conv, ok := app.Store.GetConversation(convID)
+	if !ok || !containsString(conv.MemberIDs, uid) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "conversation not found"})
+		return
+	}
+	if !containsString(conv.PendingFor, uid) && !containsString(conv.DeclinedBy, uid) {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "not a message request"})
+		return
+	}
+	conv = acceptRequest(app, convID, uid)
+	writeJSON(w, http.StatusOK, publicConversation(app, viewConversation(conv, uid)))
+}
+
+// POST /conversations/{id}/decline → 一對一：從列表移除；群組：直接退出
+func handleDeclineRequest(app *AppCtx, w http.ResponseWriter, r *http.Request, uid, convID string) {
+	conv, ok := app.Store.GetConversation(convID)
+	if !ok || !containsString(conv.MemberIDs, uid) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "conversation not found"})
+		return
+	}
+	if !containsString(conv.PendingFor, uid) {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "not a message request"})
+		return
+	}
+	if conv.Kind == store.ConversationGroup {
+		handleRemoveMember(app, w, r, uid, convID, uid)
+		return
+	}
+
+	// 只通知自己的其他裝置，發起者不會知道被拒絕
+	conv, ok = app.Store.DeclineConversationRequest(convID, uid)
+	if !ok {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "not a message request"})
+		return
+	}
+	app.Store.SaveConversations(app.Paths.ConversationsFile)
+	app.Hub.Publish(uid, publicEvent(app, realtime.Event{
+		Type:           realtime.EventConversationUpdated,
+		ConversationID: convID,
+		UserID:         uid,
+		Data:           viewConversation(conv, uid),
+	}))
+	w.WriteHeader(http.StatusNoContent)
+}
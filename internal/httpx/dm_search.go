@@ -0,0 +1,79 @@
+package httpx
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"local.dev/socialdemo-backend/internal/models"
+	"local.dev/socialdemo-backend/internal/store"
+)
+
+// ===== DM：訊息搜尋 =====
+// 比對 Text 與訊息內容裡的卡片名稱、專輯名稱等欄位；中日韓文字直接做子字串比對，全形英數視同半形
+
+// 讀 ?q= 與 ?limit=；q 空白時已寫好 400
+func searchParams(w http.ResponseWriter, r *http.Request) (string, int, bool) {
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if q == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "q is required"})
+		return "", 0, false
+	}
+	limit := 0
+	if n, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && n > 0 {
+		limit = n
+	}
+	return q, limit, true
+}
+
+// GET /conversations/{id}/messages/search?q=&limit=
+func handleSearchConversation(app *AppCtx, w http.ResponseWriter, r *http.Request, uid, convID string) {
+	conv, ok := app.Store.GetConversation(convID)
+	former, wasMember := conv.FormerMembers[uid]
+	if !ok || (!containsString(conv.MemberIDs, uid) && !wasMember) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "conversation not found"})
+		return
+	}
+	q, limit, ok := searchParams(w, r)
+	if !ok {
+		return
+	}
+	scope := store.SearchScope{ConversationID: convID}
+	if !containsString(conv.MemberIDs, uid) {
+		// 已離開的成員只搜得到離開當下為止
+		scope.Until = models.ReadCursor{MessageID: former.LastMessageID, MessageAt: former.LeftAt}
+	}
+	hits, more := app.Store.SearchMessages([]store.SearchScope{scope}, uid, q, limit)
+	writeJSON(w, http.StatusOK, map[string]any{"hits": publicHits(app, hits), "hasMore": more})
+}
+
+// GET /messages/search?q=&limit=  → 搜尋自己目前所在的所有對話
+func HandleMessageSearch(app *AppCtx) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		uid := currentUID(r)
+		q, limit, ok := searchParams(w, r)
+		if !ok {
+			return
+		}
+		convs := app.Store.ListConversationsFor(uid)
+		scopes := make([]store.SearchScope, 0, len(convs))
+		for _, c := range convs {
+			scopes = append(scopes, store.SearchScope{ConversationID: c.ID})
+		}
+		hits, more := app.Store.SearchMessages(scopes, uid, q, limit)
+		writeJSON(w, http.StatusOK, map[string]any{"hits": publicHits(app, hits), "hasMore": more})
+	}
+}
+
+func publicHits(app *AppCtx, hits []store.MessageHit) []store.MessageHit {
+	for i := range hits {
+		hits[i].Message = publicMessage(app, hits[i].Message)
+		hits[i].Before = publicMessages(app, hits[i].Before)
+		hits[i].After = publicMessages(app, hits[i].After)
+	}
+	return hits
+}
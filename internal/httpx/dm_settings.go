@@ -0,0 +1,97 @@
+package httpx
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"local.dev/socialdemo-backend/internal/store"
+)
+
+// ===== DM：自己的對話設定（靜音 / 封存 / 置頂 / 暱稱） =====
+
+const (
+	maxPinnedConversations = 5
+	maxChatNicknameLen     = 50
+)
+
+// GET /conversations/{id}/settings；PATCH 只改有帶的欄位：
+//
+//	{"muted": true}                              → 永久靜音
+//	{"mutedUntil": "2026-01-01T00:00:00Z"}       → 靜音到指定時間
+//	{"muted": false}                             → 取消靜音
+//	{"archived": true, "pinned": true, "nickname": "同好群"}
+func handleConversationSettings(app *AppCtx, w http.ResponseWriter, r *http.Request, uid, convID string) {
+	conv, ok := app.Store.GetConversation(convID)
+	if !ok || !containsString(conv.MemberIDs, uid) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "conversation not found"})
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, app.Store.GetConversationSettings(uid, convID))
+
+	case http.MethodPatch:
+		var in struct {
+			Muted      *bool   `json:"muted"`
+			MutedUntil *string `json:"mutedUntil"`
+			Archived   *bool   `json:"archived"`
+			Pinned     *bool   `json:"pinned"`
+			Nickname   *string `json:"nickname"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+			return
+		}
+
+		now := time.Now().UTC()
+		st := app.Store.GetConversationSettings(uid, convID)
+		if in.Muted != nil {
+			st.MutedUntil = ""
+			if *in.Muted {
+				st.MutedUntil = store.ForeverMuted
+			}
+		}
+		if in.MutedUntil != nil && *in.MutedUntil != "" {
+			t, err := time.Parse(time.RFC3339, *in.MutedUntil)
+			if err != nil || !t.After(now) {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "mutedUntil must be a future RFC3339 time"})
+				return
+			}
+			st.MutedUntil = t.UTC().Format(time.RFC3339)
+		}
+		if in.Archived != nil {
+			st.Archived = *in.Archived
+		}
+		if in.Pinned != nil {
+			switch {
+			case *in.Pinned && st.PinnedAt == "":
+				if app.Store.PinnedConversationCount(uid) >= maxPinnedConversations {
+					writeJSON(w, http.StatusConflict, map[string]string{"error": "too many pinned conversations"})
+					return
+				}
+				st.PinnedAt = now.Format(time.RFC3339)
+			case !*in.Pinned:
+				st.PinnedAt = ""
+			}
+		}
+		if in.Nickname != nil {
+			nick := strings.TrimSpace(*in.Nickname)
+			if utf8.RuneCountInString(nick) > maxChatNicknameLen {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "nickname too long"})
+				return
+			}
+			st.Nickname = nick
+		}
+
+		st = app.Store.SetConversationSettings(uid, convID, st)
+		app.Store.SaveConversationSettings(app.Paths.ConversationSettingsFile)
+		writeJSON(w, http.StatusOK, st)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,274 @@
+package httpx
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"local.dev/socialdemo-backend/internal/models"
+)
+
+// ===== 個人資料匯出 =====
+// POST /me/export       → 在背景打包 zip，回 202 與匯出 ID
+// GET  /me/export       → 自己的匯出紀錄
+// GET  /me/export/{id}  → 打包好就下載 zip；還在處理回 202
+//
+// zip 內容：profile、貼文、留言、按讚、追蹤、boards、封鎖 / 靜音、參與過的對話與訊息、
+// library 快照、上傳的圖片。其他使用者一律用 PublicID 表示。
+
+// 匯出檔保留多久
+const exportRetention = 7 * 24 * time.Hour
+
+func exportPath(app *AppCtx, id string) string {
+	return filepath.Join(app.Paths.ExportsDir, filepath.Base(id)+".zip")
+}
+
+// 回應前拿掉申請人的身分鍵
+func publicExport(e models.DataExport) models.DataExport {
+	e.OwnerID = ""
+	return e
+}
+
+func HandleMyExport(app *AppCtx) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uid := currentUID(r)
+		id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/me/export"), "/")
+
+		if id == "" {
+			switch r.Method {
+			case http.MethodGet:
+				list := app.Store.ListExports(uid)
+				for i := range list {
+					list[i] = publicExport(list[i])
+				}
+				writeJSON(w, http.StatusOK, list)
+			case http.MethodPost:
+				e, created := app.Store.CreateExport(uid, time.Now().UTC())
+				if !created {
+					writeJSON(w, http.StatusConflict, map[string]any{"error": "an export is already in progress", "export": publicExport(e)})
+					return
+				}
+				app.Store.SaveExports(app.Paths.ExportsFile)
+				go buildExport(app, e)
+				writeJSON(w, http.StatusAccepted, publicExport(e))
+			default:
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+			return
+		}
+
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		e, ok := app.Store.GetExport(id)
+		if !ok || e.OwnerID != uid {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "export not found"})
+			return
+		}
+		switch e.Status {
+		case models.ExportPending:
+			writeJSON(w, http.StatusAccepted, publicExport(e))
+			return
+		case models.ExportFailed:
+			writeJSON(w, http.StatusConflict, map[string]any{"error": "export failed, please request a new one", "export": publicExport(e)})
+			return
+		}
+
+		f, err := os.Open(exportPath(app, e.ID))
+		if err != nil {
+			writeJSON(w, http.StatusGone, map[string]string{"error": "export file is no longer available"})
+			return
+		}
+		defer f.Close()
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", `attachment; filename="export-`+e.ID+`.zip"`)
+		http.ServeContent(w, r, "", parseISO(e.CompletedAt), f)
+	}
+}
+
+// 背景打包：先寫暫存檔，完成後才換成正式檔名
+func buildExport(app *AppCtx, e models.DataExport) {
+	start := time.Now()
+	_ = os.MkdirAll(app.Paths.ExportsDir, 0o755)
+	dst := exportPath(app, e.ID)
+	tmp := dst + ".tmp"
+
+	size, err := writeExportZip(app, e.OwnerID, tmp)
+	if err == nil {
+		// 打包期間紀錄被移除（或已不是進行中）就不留下檔案
+		if cur, ok := app.Store.GetExport(e.ID); !ok || cur.Status != models.ExportPending {
+			_ = os.Remove(tmp)
+			log.Printf("[export] discarded id=%s: record no longer pending", e.ID)
+			return
+		}
+		err = os.Rename(tmp, dst)
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		log.Printf("[export] failed id=%s: %v", e.ID, err)
+	}
+	app.Store.FinishExport(e.ID, size, err, time.Now().UTC(), exportRetention)
+	app.Store.SaveExports(app.Paths.ExportsFile)
+	log.Printf("[export] id=%s size=%d took=%s", e.ID, size, time.Since(start).Round(time.Millisecond))
+}
+
+func writeExportZip(app *AppCtx, uid, path string) (int64, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+	zw := zip.NewWriter(f)
+	now := time.Now()
+
+	create := func(name string, method uint16) (io.Writer, error) {
+		return zw.CreateHeader(&zip.FileHeader{Name: name, Method: method, Modified: now})
+	}
+	addJSON := func(name string, v any) error {
+		wr, err := create(name, zip.Deflate)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(wr)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+	summaries := func(ids []string) []models.User {
+		out := make([]models.User, 0, len(ids))
+		for _, id := range ids {
+			out = append(out, userSummary(app, id))
+		}
+		return out
+	}
+
+	err = func() error {
+		// profile：本人看到的完整資料，加上登入識別碼與標籤
+		prof, ok := app.Store.GetProfile(uid)
+		if !ok {
+			prof = models.Profile{ID: uid}
+		}
+		prof = withFollowStats(app, prof, uid)
+		if err := addJSON("profile.json", map[string]any{
+			"profile":    prof,
+			"identities": app.Store.ListIdentities(uid),
+			"tags":       app.Store.GetTags(uid),
+		}); err != nil {
+			return err
+		}
+
+		content := app.Store.UserContent(uid)
+		hydratePostAuthors(app, content.Posts)
+		for i := range content.Comments {
+			content.Comments[i].Author = userSummary(app, content.Comments[i].Author.ID)
+		}
+		if err := addJSON("posts.json", content.Posts); err != nil {
+			return err
+		}
+		if err := addJSON("comments.json", content.Comments); err != nil {
+			return err
+		}
+		if err := addJSON("likes.json", map[string]any{"postIds": content.LikedPosts}); err != nil {
+			return err
+		}
+		if err := addJSON("boards.json", publicBoards(app, content.Boards)); err != nil {
+			return err
+		}
+
+		requests := app.Store.ListFollowRequests(uid)
+		for i := range requests {
+			requests[i].UserID = pubID(app, requests[i].UserID)
+		}
+		if err := addJSON("follows.json", map[string]any{
+			"following":       summaries(app.Store.GetFriends(uid)),
+			"followers":       summaries(app.Store.GetFollowers(uid)),
+			"pendingRequests": requests,
+		}); err != nil {
+			return err
+		}
+
+		blocked := app.Store.ListBlocked(uid)
+		for i := range blocked {
+			blocked[i].UserID = pubID(app, blocked[i].UserID)
+		}
+		if err := addJSON("blocks.json", blocked); err != nil {
+			return err
+		}
+		if err := addJSON("mutes.json", publicMutes(app, app.Store.GetMutes(uid))); err != nil {
+			return err
+		}
+
+		convs := app.Store.UserConversations(uid)
+		list := make([]models.Conversation, 0, len(convs))
+		for _, c := range convs {
+			// 跟 API 一樣套用 viewConversation：拒絕請求的狀態、未接受者的已讀不給發起者看
+			list = append(list, publicConversation(app, viewConversation(c.Conversation, uid)))
+			if err := addJSON("messages/"+c.Conversation.ID+".json", publicMessages(app, c.Messages)); err != nil {
+				return err
+			}
+		}
+		if err := addJSON("conversations.json", list); err != nil {
+			return err
+		}
+
+		// library 快照（含合併帳號時留下的舊版本）原樣放進去
+		libraries := map[string]string{libraryPath(app, uid): "library.json"}
+		for _, path := range libraryBackups(app, uid) {
+			libraries[path] = "library" + strings.TrimPrefix(filepath.Base(path), "library_"+uid)
+		}
+		for path, name := range libraries {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				continue
+			}
+			wr, err := create(name, zip.Deflate)
+			if err != nil {
+				return err
+			}
+			if _, err := wr.Write(data); err != nil {
+				return err
+			}
+		}
+
+		// 上傳的圖片（檔案已經不在的略過）
+		for _, url := range app.Store.UserUploads(uid) {
+			name := filepath.Base(url)
+			src, err := os.Open(filepath.Join(app.Paths.UploadsDir, name))
+			if err != nil {
+				continue
+			}
+			wr, err := create("uploads/"+name, zip.Store) // 圖片本身已壓縮
+			if err == nil {
+				_, err = io.Copy(wr, src)
+			}
+			src.Close()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}()
+
+	err = errors.Join(err, zw.Close(), f.Close())
+	if err != nil {
+		return 0, err
+	}
+	st, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	return st.Size(), nil
+}
+
+// 刪掉匯出檔（過期、或帳號被清除時）
+func removeExports(app *AppCtx, ids []string) {
+	for _, id := range ids {
+		_ = os.Remove(exportPath(app, id))
+	}
+}
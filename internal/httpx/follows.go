@@ -0,0 +1,222 @@
+package httpx
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"local.dev/socialdemo-backend/internal/models"
+)
+
+// ===== 追蹤：粉絲 / 追蹤中列表、統計、互相追蹤 =====
+
+const (
+	defaultFollowPage = 50
+	maxFollowPage     = 200
+)
+
+// 列表裡的一個人；mutual = 跟 {id} 互相追蹤
+type followEntry struct {
+	models.User
+	Mutual       bool `json:"mutual"`
+	FollowedByMe bool `json:"followedByMe"`
+}
+
+// 補上追蹤統計，以及 viewer 跟這個人的關係（看自己時不帶關係）
+// 回應裡的 id 換成 PublicID
+func withFollowStats(app *AppCtx, p models.Profile, viewer string) models.Profile {
+	p.FollowerCount, p.FollowingCount = app.Store.FollowCounts(p.ID)
+	if viewer != "" && viewer != p.ID {
+		p.FollowedByMe = app.Store.IsFollowing(viewer, p.ID)
+		p.FollowsMe = app.Store.IsFollowing(p.ID, viewer)
+		p.Mutual = p.FollowedByMe && p.FollowsMe
+		p.FollowRequested = !p.FollowedByMe && app.Store.HasFollowRequest(viewer, p.ID)
+	}
+	p.PublicID = pubID(app, p.ID)
+	p.ID = p.PublicID
+	return p
+}
+
+// GET /users/{id}/followers、/users/{id}/following?cursor=&limit=
+// 依使用者 ID 排序；cursor 是上一頁最後一個人的 ID
+func handleFollowList(app *AppCtx, w http.ResponseWriter, r *http.Request, userID string, followers bool) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	viewer := tryViewerUID(app, r)
+	if !app.Store.CanViewPrivate(userID, viewer) {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "this account is private"})
+		return
+	}
+
+	var ids []string
+	if followers {
+		ids = app.Store.GetFollowers(userID)
+	} else {
+		ids = app.Store.GetFriends(userID)
+	}
+	// 封鎖關係的另一方不列出；先濾掉再算 total / 分頁，每頁筆數才會對
+	if viewer != "" {
+		visible := ids[:0:0]
+		for _, id := range ids {
+			if !app.Store.IsBlockedEither(viewer, id) {
+				visible = append(visible, id)
+			}
+		}
+		ids = visible
+	}
+	total := len(ids)
+
+	limit := defaultFollowPage
+	if n, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && n > 0 {
+		limit = min(n, maxFollowPage)
+	}
+	start := 0
+	if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+		if id, ok := resolveUser(app, r, cursor); ok {
+			cursor = id
+		}
+		start = sort.SearchStrings(ids, cursor)
+		if start < len(ids) && ids[start] == cursor {
+			start++
+		}
+	}
+	end := min(start+limit, len(ids))
+
+	out := make([]followEntry, 0, end-start)
+	for _, id := range ids[start:end] {
+		var mutual bool
+		if followers {
+			mutual = app.Store.IsFollowing(userID, id)
+		} else {
+			mutual = app.Store.IsFollowing(id, userID)
+		}
+		out = append(out, followEntry{
+			User:         userSummary(app, id),
+			Mutual:       mutual,
+			FollowedByMe: viewer != "" && app.Store.IsFollowing(viewer, id),
+		})
+	}
+
+	next := ""
+	if end < len(ids) {
+		next = pubID(app, ids[end-1])
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"users":      out,
+		"total":      total,
+		"nextCursor": next,
+	})
+}
+
+// 顯示用的精簡資料（PublicID / 名稱 / 頭像 / 徽章）
+func userSummary(app *AppCtx, uid string) models.User {
+	id := pubID(app, uid)
+	if id == "" {
+		return models.User{Name: erasedUserName} // 帳號已刪除（內容依政策保留）或已不存在的作者
+	}
+	if prof, ok := app.Store.GetProfile(uid); ok {
+		return models.User{
+			ID:            id,
+			Name:          displayNameFromProfile(prof),
+			AvatarURL:     prof.AvatarURL,
+			VerifiedBadge: prof.VerifiedBadge,
+		}
+	}
+	return models.User{ID: id, Name: id}
+}
+
+// GET  /me/follow-requests                     → 等我核准的追蹤請求
+// POST /me/follow-requests/{uid}/approve
+// POST /me/follow-requests/{uid}/decline
+func HandleFollowRequests(app *AppCtx) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uid := currentUID(r)
+		rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/me/follow-requests"), "/")
+		if rest == "" {
+			if r.Method != http.MethodGet {
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			reqs := app.Store.ListFollowRequests(uid)
+			out := make([]map[string]any, 0, len(reqs))
+			for _, fr := range reqs {
+				out = append(out, map[string]any{
+					"user":        userSummary(app, fr.UserID),
+					"requestedAt": fr.RequestedAt,
+				})
+			}
+			writeJSON(w, http.StatusOK, out)
+			return
+		}
+
+		parts := strings.Split(rest, "/")
+		if len(parts) != 2 {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		requester, found := resolveUserOr404(app, w, r, parts[0])
+		if !found {
+			return
+		}
+		var ok bool
+		switch parts[1] {
+		case "approve":
+			if ok = app.Store.ApproveFollowRequest(uid, requester); ok {
+				app.Store.SaveFriends(app.Paths.FriendsFile)
+			}
+		case "decline":
+			ok = app.Store.RemoveFollowRequest(uid, requester)
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "follow request not found"})
+			return
+		}
+		app.Store.SaveFollowRequests(app.Paths.FollowRequestsFile)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+const (
+	defaultSuggestions = 20
+	maxSuggestions     = 50
+)
+
+// GET /me/suggestions?limit= → 推薦追蹤（背景工作算好的快取）
+func HandleMySuggestions(app *AppCtx) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		limit := defaultSuggestions
+		if n, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && n > 0 {
+			limit = min(n, maxSuggestions)
+		}
+		items, computedAt := app.Store.GetSuggestions(currentUID(r), limit)
+		out := make([]map[string]any, 0, len(items))
+		for _, sg := range items {
+			out = append(out, map[string]any{
+				"user":          userSummary(app, sg.UserID),
+				"score":         sg.Score,
+				"mutualFollows": sg.MutualFollows,
+				"sharedTags":    sg.SharedTags,
+				"sharedBoards":  sg.SharedBoards,
+				"coLikes":       sg.CoLikes,
+			})
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"users":      out,
+			"computedAt": computedAt,
+		})
+	}
+}
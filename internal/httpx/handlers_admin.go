@@ -1,6 +1,33 @@
 package httpx
 
-import "net/http"
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"local.dev/socialdemo-backend/internal/config"
+	"local.dev/socialdemo-backend/internal/models"
+	"local.dev/socialdemo-backend/internal/store"
+)
+
+// --- 管理員判斷：身分鍵在 ADMIN_IDS 名單內才算（需先經過 WithAuth） ---
+func isAdmin(_ *AppCtx, r *http.Request) bool {
+	uid := currentUID(r)
+	if uid == "" {
+		return false
+	}
+	_, ok := config.AdminIDs()[uid]
+	return ok
+}
+
+// 可指派的認證徽章種類
+var allowedBadges = map[string]bool{
+	"verified": true,
+	"official": true,
+	"artist":   true,
+	"staff":    true,
+}
 
 func HandleAdminReload(app *AppCtx) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -13,3 +40,178 @@ func HandleAdminReload(app *AppCtx) http.HandlerFunc {
 		writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
 	}
 }
+
+// GET  /admin/boards/official        → 列出所有官方版
+// POST /admin/boards/official        → 建立官方版，或帶 boardId 把既有 board 轉成官方版
+// DELETE /admin/boards/official/{id} → 取消官方身分（board 本身保留）
+func HandleAdminOfficialBoards(app *AppCtx) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !isAdmin(app, r) {
+			writeJSON(w, http.StatusForbidden, map[string]string{"error": "admin only"})
+			return
+		}
+		uid := currentUID(r)
+		rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/admin/boards/official"), "/")
+
+		// /admin/boards/official/{id}
+		if rest != "" {
+			if r.Method != http.MethodDelete {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			// 取消官方身分時團體名稱一併清掉，之後同一個團體才能再指定新的官方版
+			b, err := app.Store.UpdateBoard(rest, func(b *models.Board) error {
+				if b.Deleted {
+					return store.ErrBoardNotFound
+				}
+				b.IsOfficial = false
+				b.IdolGroup = ""
+				b.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+				return nil
+			})
+			if err != nil {
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": "board not found"})
+				return
+			}
+			app.Store.SaveBoards(app.Paths.BoardsFile)
+			writeJSON(w, http.StatusOK, publicBoard(app, b))
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, publicBoards(app, app.Store.ListOfficialBoards()))
+
+		case http.MethodPost:
+			var in struct {
+				BoardID     string `json:"boardId"`
+				Name        string `json:"name"`
+				Description string `json:"description"`
+				IdolGroup   string `json:"idolGroup"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+				return
+			}
+			group := strings.TrimSpace(in.IdolGroup)
+			if group == "" {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "idolGroup is required"})
+				return
+			}
+
+			// 每個偶像團體只能有一個官方版
+			if ex, ok := app.Store.FindOfficialBoard(group); ok && ex.ID != in.BoardID {
+				writeJSON(w, http.StatusConflict, map[string]string{
+					"error":   "official board already exists for this group",
+					"boardId": ex.ID,
+				})
+				return
+			}
+
+			now := time.Now().UTC().Format(time.RFC3339)
+			status := http.StatusOK
+
+			var b models.Board
+			if in.BoardID != "" {
+				// 轉換既有 board
+				ex, ok := app.Store.GetBoard(in.BoardID)
+				if !ok || ex.Deleted {
+					writeJSON(w, http.StatusNotFound, map[string]string{"error": "board not found"})
+					return
+				}
+				b = ex
+				if name := strings.TrimSpace(in.Name); name != "" {
+					b.Name = name
+				}
+				if in.Description != "" {
+					b.Description = strings.TrimSpace(in.Description)
+				}
+			} else {
+				name := strings.TrimSpace(in.Name)
+				if name == "" {
+					writeJSON(w, http.StatusBadRequest, map[string]string{"error": "name is required"})
+					return
+				}
+				b = models.Board{
+					Name:         name,
+					Description:  strings.TrimSpace(in.Description),
+					OwnerID:      uid,
+					ModeratorIDs: []string{},
+					CreatedAt:    now,
+				}
+				status = http.StatusCreated
+			}
+
+			// 官方版一律公開
+			b.IsOfficial = true
+			b.IsPrivate = false
+			b.IdolGroup = group
+			b.UpdatedAt = now
+
+			b = app.Store.SaveBoard(b)
+			app.Store.SaveBoards(app.Paths.BoardsFile)
+			writeJSON(w, status, publicBoard(app, b))
+
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// PUT    /admin/users/{id}/badge  body: {"badge":"verified"}
+// DELETE /admin/users/{id}/badge
+func HandleAdminUsers(app *AppCtx) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !isAdmin(app, r) {
+			writeJSON(w, http.StatusForbidden, map[string]string{"error": "admin only"})
+			return
+		}
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/admin/users/"), "/")
+		if len(parts) != 2 || parts[0] == "" || parts[1] != "badge" {
+			http.NotFound(w, r)
+			return
+		}
+		userID, ok := resolveUserOr404(app, w, r, parts[0]) // 也接受 PublicID / @handle
+		if !ok {
+			return
+		}
+
+		switch r.Method {
+		case http.MethodPut:
+			var in struct {
+				Badge string `json:"badge"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+				return
+			}
+			badge := strings.ToLower(strings.TrimSpace(in.Badge))
+			if badge == "" {
+				badge = "verified"
+			}
+			if !allowedBadges[badge] {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unknown badge"})
+				return
+			}
+			p, ok := app.Store.SetVerifiedBadge(userID, badge)
+			if !ok {
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": "user not found"})
+				return
+			}
+			app.Store.SaveProfiles(app.Paths.ProfilesFile)
+			writeJSON(w, http.StatusOK, publicProfile(app, p, tryViewerUID(app, r))) // id 換成 PublicID，不回身分鍵
+
+		case http.MethodDelete:
+			p, ok := app.Store.SetVerifiedBadge(userID, "")
+			if !ok {
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": "user not found"})
+				return
+			}
+			app.Store.SaveProfiles(app.Paths.ProfilesFile)
+			writeJSON(w, http.StatusOK, publicProfile(app, p, tryViewerUID(app, r)))
+
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}
+}
@@ -13,6 +13,11 @@ import (
 // 我們不強制 schema，直接把 body 解成 map[string]any
 type LibraryPayload map[string]any
 
+// data/library_<uid>.json
+func libraryPath(app *AppCtx, uid string) string {
+	return filepath.Join(app.Paths.DataDir, "library_"+uid+".json")
+}
+
 // /api/v1/library/sync
 //
 // 流程：
@@ -51,8 +56,7 @@ func HandleLibrarySync(app *AppCtx) http.HandlerFunc {
 		}
 
 		// 4) 存檔：data/library_<uid>.json
-		filename := "library_" + uid + ".json"
-		path := filepath.Join(app.Paths.DataDir, filename)
+		path := libraryPath(app, uid)
 
 		wrapped := map[string]any{
 			"user_id":    uid,
@@ -107,8 +111,7 @@ func HandleLibrarySnapshot(app *AppCtx) http.HandlerFunc {
 			return
 		}
 
-		filename := "library_" + uid + ".json"
-		path := filepath.Join(app.Paths.DataDir, filename)
+		path := libraryPath(app, uid)
 
 		data, err := os.ReadFile(path)
 		if err != nil {
@@ -2,10 +2,14 @@ package httpx
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"local.dev/socialdemo-backend/internal/models"
+	"local.dev/socialdemo-backend/internal/store"
 )
 
 // 片段（你現有的 HandleMe 基本上就是這樣）
@@ -15,20 +19,33 @@ func HandleMe(app *AppCtx) http.HandlerFunc {
 		switch r.Method {
 		case http.MethodGet:
 			if p, ok := app.Store.GetProfile(key); ok {
-				writeJSON(w, http.StatusOK, p)
+				writeJSON(w, http.StatusOK, withFollowStats(app, p, key))
 				return
 			}
-			writeJSON(w, http.StatusOK, models.Profile{ID: key, Name: key})
+			writeJSON(w, http.StatusOK, withFollowStats(app, models.Profile{ID: key}, key))
 		case http.MethodPatch:
-			var p models.Profile
-			if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
-				http.Error(w, err.Error(), http.StatusBadRequest)
+			// RFC 7396 JSON Merge Patch：沒帶的欄位不動，null 代表清掉
+			patch, ok := readMergePatch(w, r)
+			if !ok {
+				return
+			}
+			before, _ := app.Store.GetProfile(key)
+			updated, err := app.Store.UpdateProfile(key, func(cur models.Profile) (models.Profile, error) {
+				return applyProfilePatch(cur, patch)
+			})
+			if err != nil {
+				writeProfileErrors(w, err)
 				return
 			}
-			p.ID = key // ← 強制用後端認的身分鍵
-			updated := app.Store.UpsertProfile(p)
 			app.Store.SaveProfiles(app.Paths.ProfilesFile)
-			writeJSON(w, http.StatusOK, updated)
+			// 私人帳號改回公開：等待中的追蹤請求全部核准
+			if before.Private && !updated.Private && app.Store.ApproveAllFollowRequests(key) > 0 {
+				app.Store.SaveFriends(app.Paths.FriendsFile)
+				app.Store.SaveFollowRequests(app.Paths.FollowRequestsFile)
+			}
+			writeJSON(w, http.StatusOK, withFollowStats(app, updated, key))
+		case http.MethodDelete:
+			handleDeleteMe(app, w, r, key) // account_deletion.go
 		default:
 			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		}
@@ -83,6 +100,146 @@ func HandleMyFriends(app *AppCtx) http.HandlerFunc {
 			return
 		}
 		uid := currentUID(r)
-		writeJSON(w, http.StatusOK, app.Store.GetFriends(uid))
+		writeJSON(w, http.StatusOK, pubIDs(app, app.Store.GetFriends(uid)))
+	}
+}
+
+// 每一類靜音最多幾筆
+const maxMutesPerKind = 200
+
+// GET /me/mutes；PUT /me/mutes 整份取代：
+//
+//	{"accounts":[{"value":"u_xxx"}], "tags":[{"value":"spoiler","expiresAt":"2026-01-01T00:00:00Z"}], "keywords":[{"value":"劇透"}]}
+func HandleMyMutes(app *AppCtx) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uid := currentUID(r)
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, publicMutes(app, app.Store.GetMutes(uid)))
+		case http.MethodPut:
+			var in models.MuteList
+			if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			// 帳號可以用 PublicID / @handle 指定，存的是身分鍵；找不到的帳號整份拒絕
+			for i, e := range in.Accounts {
+				if strings.TrimSpace(e.Value) == "" {
+					continue
+				}
+				id, ok := resolveUser(app, r, e.Value)
+				if !ok {
+					writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unknown account", "value": e.Value})
+					return
+				}
+				in.Accounts[i].Value = id
+			}
+			var err error
+			if in.Accounts, err = cleanMutes(in.Accounts, strings.TrimSpace); err == nil {
+				if in.Tags, err = cleanMutes(in.Tags, func(v string) string {
+					return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(v), "#"))
+				}); err == nil {
+					in.Keywords, err = cleanMutes(in.Keywords, strings.TrimSpace)
+				}
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			updated := app.Store.SetMutes(uid, in)
+			app.Store.SaveMutes(app.Paths.MutesFile)
+			writeJSON(w, http.StatusOK, publicMutes(app, updated))
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// 靜音的帳號換成 PublicID 再回傳（帳號已不存在的略過）
+func publicMutes(app *AppCtx, m models.MuteList) models.MuteList {
+	accounts := make([]models.MuteEntry, 0, len(m.Accounts))
+	for _, e := range m.Accounts {
+		if e.Value = pubID(app, e.Value); e.Value != "" {
+			accounts = append(accounts, e)
+		}
+	}
+	m.Accounts = accounts
+	return m
+}
+
+// 正規化 + 去重；expiresAt 必須是 RFC3339（過去的時間會在存檔時被清掉）
+func cleanMutes(list []models.MuteEntry, norm func(string) string) ([]models.MuteEntry, error) {
+	if len(list) > maxMutesPerKind {
+		return nil, fmt.Errorf("too many mutes (max %d)", maxMutesPerKind)
+	}
+	seen := map[string]struct{}{}
+	out := make([]models.MuteEntry, 0, len(list))
+	for _, e := range list {
+		v := norm(e.Value)
+		if v == "" {
+			continue
+		}
+		if _, dup := seen[v]; dup {
+			continue
+		}
+		if e.ExpiresAt != "" {
+			t, err := time.Parse(time.RFC3339, e.ExpiresAt)
+			if err != nil {
+				return nil, fmt.Errorf("invalid expiresAt for %q", v)
+			}
+			e.ExpiresAt = t.UTC().Format(time.RFC3339)
+		}
+		seen[v] = struct{}{}
+		out = append(out, models.MuteEntry{Value: v, ExpiresAt: e.ExpiresAt})
+	}
+	return out, nil
+}
+
+// GET /me/handle?check=xxx → 這個 handle 能不能用
+// PUT /me/handle  body: {"handle": "xxx"}（空字串 = 移除）
+func HandleMyHandle(app *AppCtx) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uid := currentUID(r)
+		switch r.Method {
+		case http.MethodGet:
+			h := r.URL.Query().Get("check")
+			if err := app.Store.HandleAvailable(uid, h); err != nil {
+				writeJSON(w, http.StatusOK, map[string]any{"handle": store.NormalizeHandle(h), "available": false, "reason": err.Error()})
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]any{"handle": store.NormalizeHandle(h), "available": true})
+		case http.MethodPut:
+			var in struct {
+				Handle string `json:"handle"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			p, err := app.Store.SetHandle(uid, in.Handle)
+			if err != nil {
+				writeHandleError(w, err)
+				return
+			}
+			app.Store.SaveProfiles(app.Paths.ProfilesFile)
+			writeJSON(w, http.StatusOK, withFollowStats(app, p, uid))
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+func writeHandleError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, store.ErrHandleInvalid):
+		status = http.StatusUnprocessableEntity
+	case errors.Is(err, store.ErrHandleTaken), errors.Is(err, store.ErrHandleReserved):
+		status = http.StatusConflict
+	case errors.Is(err, store.ErrHandleCooldown):
+		status = http.StatusTooManyRequests
+	case errors.Is(err, store.ErrAccountUnknown):
+		status = http.StatusNotFound
 	}
+	writeJSON(w, status, map[string]string{"error": err.Error()})
 }
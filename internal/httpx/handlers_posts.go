@@ -3,8 +3,6 @@ package httpx
 import (
 	"encoding/json"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strings"
 	"time"
 
@@ -99,7 +97,7 @@ func HandlePostDetail(app *AppCtx) http.HandlerFunc {
 						http.Error(w, "not found", http.StatusNotFound)
 						return
 					}
-					if currentUID(r) != p.Author.ID && !isAdmin(app, r) {
+					if currentUID(r) != p.Author.ID && !canModeratePosts(app, r) {
 						http.Error(w, "forbidden", http.StatusForbidden)
 						return
 					}
@@ -108,7 +106,7 @@ func HandlePostDetail(app *AppCtx) http.HandlerFunc {
 					updated := app.Store.UpdateAt(idx, p)
 					app.Store.SavePosts(app.Paths.PostsFile)
 
-					decorated := app.Store.Decorate(updated, currentUID(r))
+					decorated := app.Store.FilterBlockedComments(app.Store.Decorate(updated, currentUID(r)), currentUID(r))
 					tmp := []models.Post{decorated}
 					hydratePostAuthors(app, tmp)
 					writeJSON(w, http.StatusOK, tmp[0])
@@ -121,13 +119,13 @@ func HandlePostDetail(app *AppCtx) http.HandlerFunc {
 						http.Error(w, "not found", http.StatusNotFound)
 						return
 					}
-					if currentUID(r) != p.Author.ID && !isAdmin(app, r) {
+					if currentUID(r) != p.Author.ID && !canModeratePosts(app, r) {
 						http.Error(w, "forbidden", http.StatusForbidden)
 						return
 					}
 
-					if p.ImageURL != nil && strings.HasPrefix(*p.ImageURL, "/uploads/") {
-						_ = os.Remove(filepath.Join(app.Paths.UploadsDir, filepath.Base(*p.ImageURL)))
+					if p.ImageURL != nil {
+						removeUpload(app, *p.ImageURL)
 					}
 					app.Store.DeleteAt(idx)
 					app.Store.SavePosts(app.Paths.PostsFile)
@@ -150,6 +148,10 @@ func HandlePostDetail(app *AppCtx) http.HandlerFunc {
 					return
 				}
 				uid := currentUID(r)
+				if post, idx := app.Store.ByID(id); idx >= 0 && app.Store.IsBlockedEither(uid, post.Author.ID) {
+					http.Error(w, "forbidden", http.StatusForbidden) // 封鎖關係：不能按讚
+					return
+				}
 				p, ok := app.Store.ToggleLike(id, uid)
 				if !ok {
 					http.Error(w, "not found", http.StatusNotFound)
@@ -157,7 +159,7 @@ func HandlePostDetail(app *AppCtx) http.HandlerFunc {
 				}
 				app.Store.SaveLikes(app.Paths.LikesFile)
 
-				decorated := app.Store.Decorate(p, uid)
+				decorated := app.Store.FilterBlockedComments(app.Store.Decorate(p, uid), uid)
 				tmp := []models.Post{decorated}
 				hydratePostAuthors(app, tmp)
 				writeJSON(w, http.StatusOK, tmp[0])
@@ -183,6 +185,10 @@ func HandlePostDetail(app *AppCtx) http.HandlerFunc {
 					http.Error(w, "not found", http.StatusNotFound)
 					return
 				}
+				if app.Store.IsBlockedEither(uid, p.Author.ID) {
+					http.Error(w, "forbidden", http.StatusForbidden) // 封鎖關係：不能留言
+					return
+				}
 
 				p.Comments = append(p.Comments, models.Comment{
 					ID:        time.Now().Format("20060102T150405.000000000"),
@@ -194,7 +200,7 @@ func HandlePostDetail(app *AppCtx) http.HandlerFunc {
 				updated := app.Store.UpdateAt(idx, p)
 				app.Store.SavePosts(app.Paths.PostsFile)
 
-				decorated := app.Store.Decorate(updated, uid)
+				decorated := app.Store.FilterBlockedComments(app.Store.Decorate(updated, uid), uid)
 				tmp := []models.Post{decorated}
 				hydratePostAuthors(app, tmp)
 				writeJSON(w, http.StatusOK, tmp[0])
@@ -206,8 +212,8 @@ func HandlePostDetail(app *AppCtx) http.HandlerFunc {
 	}
 }
 
-// --- 管理員判斷（目前預設關閉；僅作者可刪/改）。之後要開放可在這裡實作 ---
-func isAdmin(_ *AppCtx, _ *http.Request) bool { return false }
+// --- 貼文管理權限（目前預設關閉；僅作者可刪/改）。ADMIN_IDS 只開放 /admin/*，不包含改刪別人的貼文 ---
+func canModeratePosts(_ *AppCtx, _ *http.Request) bool { return false }
 
 // POST /posts/query
 func HandlePostsQuery(app *AppCtx) http.HandlerFunc {
@@ -232,7 +238,13 @@ func HandlePostsQuery(app *AppCtx) http.HandlerFunc {
 		}
 
 		viewer := currentUID(r)
-		out := app.Store.ListByAuthors(req.FriendIDs, req.Tags, viewer)
+		authors := make([]string, 0, len(req.FriendIDs))
+		for _, ref := range req.FriendIDs {
+			if id, ok := resolveUser(app, r, ref); ok {
+				authors = append(authors, id)
+			}
+		}
+		out := app.Store.ListByAuthors(authors, req.Tags, viewer)
 		if out == nil {
 			out = make([]models.Post, 0)
 		}
@@ -249,30 +261,25 @@ func displayNameFromProfile(p models.Profile) string {
 	if p.Name != "" {
 		return p.Name
 	}
-	return p.ID
+	if p.Handle != "" {
+		return "@" + p.Handle
+	}
+	return p.PublicID // 不拿身分鍵（email）當名字
 }
 
 func hydratePostAuthors(app *AppCtx, posts []models.Post) {
 	for i := range posts {
 		// post author
-		if prof, ok := app.Store.GetProfile(posts[i].Author.ID); ok {
-			posts[i].Author.Name = displayNameFromProfile(prof)
-			posts[i].Author.AvatarURL = prof.AvatarURL
-		} else {
-			posts[i].Author.Name = posts[i].Author.ID
-			posts[i].Author.AvatarURL = nil
-		}
-
-		// comments author
-		for j := range posts[i].Comments {
-			uid := posts[i].Comments[j].Author.ID
-			if prof, ok := app.Store.GetProfile(uid); ok {
-				posts[i].Comments[j].Author.Name = displayNameFromProfile(prof)
-				posts[i].Comments[j].Author.AvatarURL = prof.AvatarURL
-			} else {
-				posts[i].Comments[j].Author.Name = uid
-				posts[i].Comments[j].Author.AvatarURL = nil
+		posts[i].Author = userSummary(app, posts[i].Author.ID)
+
+		// comments author（Comments 跟 Store 共用底層陣列，先複製再改，不然會把 PublicID 寫回存檔）
+		if posts[i].Comments != nil {
+			comments := make([]models.Comment, len(posts[i].Comments))
+			copy(comments, posts[i].Comments)
+			for j := range comments {
+				comments[j].Author = userSummary(app, comments[j].Author.ID)
 			}
+			posts[i].Comments = comments
 		}
 	}
 }
@@ -94,3 +94,11 @@ func HandleUpload(app *AppCtx) http.HandlerFunc {
 		writeJSON(w, http.StatusOK, map[string]string{"url": "/uploads/" + filename})
 	}
 }
+
+// 刪除 /uploads/ 底下的實體檔案（外部 URL 直接略過）
+func removeUpload(app *AppCtx, url string) {
+	if !strings.HasPrefix(url, "/uploads/") {
+		return
+	}
+	_ = os.Remove(filepath.Join(app.Paths.UploadsDir, filepath.Base(url)))
+}
@@ -3,8 +3,6 @@ package httpx
 import (
 	"net/http"
 	"strings"
-
-	"local.dev/socialdemo-backend/internal/models"
 )
 
 func HandleUsers(app *AppCtx) http.HandlerFunc {
@@ -15,18 +13,20 @@ func HandleUsers(app *AppCtx) http.HandlerFunc {
 			return
 		}
 		parts := strings.Split(rest, "/")
-		userId := parts[0]
+		// /users/{publicId}、/users/@handle（身分鍵只接受本人的）
+		userId, ok := resolveUserOr404(app, w, r, parts[0])
+		if !ok {
+			return
+		}
 
 		if len(parts) == 1 {
 			if r.Method != http.MethodGet {
 				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 				return
 			}
-			if p, ok := app.Store.GetProfile(userId); ok {
-				writeJSON(w, http.StatusOK, p)
-				return
-			}
-			writeJSON(w, http.StatusOK, models.Profile{ID: userId, Name: userId})
+			viewer := tryViewerUID(app, r)
+			p, _ := app.Store.GetProfile(userId)
+			writeJSON(w, http.StatusOK, publicProfile(app, p, viewer))
 			return
 		}
 
@@ -37,18 +37,39 @@ func HandleUsers(app *AppCtx) http.HandlerFunc {
 				return
 			}
 			viewer := tryViewerUID(app, r)
-			writeJSON(w, http.StatusOK, app.Store.UserPosts(userId, viewer))
+			if !app.Store.CanViewPrivate(userId, viewer) {
+				writeJSON(w, http.StatusForbidden, map[string]string{"error": "this account is private"})
+				return
+			}
+			posts := app.Store.UserPosts(userId, viewer)
+			hydratePostAuthors(app, posts)
+			writeJSON(w, http.StatusOK, posts)
 
 		case "follow":
 			WithAuth(app, func(w http.ResponseWriter, r *http.Request) {
 				uid := currentUID(r)
 				switch r.Method {
 				case http.MethodPost:
+					if app.Store.IsBlockedEither(uid, userId) {
+						http.Error(w, "forbidden", http.StatusForbidden)
+						return
+					}
+					// 私人帳號：先送出請求，等對方核准（202）
+					if prof, ok := app.Store.GetProfile(userId); ok && prof.Private && !app.Store.IsFollowing(uid, userId) {
+						app.Store.RequestFollow(uid, userId)
+						app.Store.SaveFollowRequests(app.Paths.FollowRequestsFile)
+						writeJSON(w, http.StatusAccepted, map[string]string{"status": "requested"})
+						return
+					}
 					app.Store.Follow(uid, userId)
 					app.Store.SaveFriends(app.Paths.FriendsFile)
 					w.WriteHeader(http.StatusNoContent)
 				case http.MethodDelete:
+					// 取消追蹤，也一併取消還在等待的請求
 					app.Store.Unfollow(uid, userId)
+					if app.Store.RemoveFollowRequest(userId, uid) {
+						app.Store.SaveFollowRequests(app.Paths.FollowRequestsFile)
+					}
 					app.Store.SaveFriends(app.Paths.FriendsFile)
 					w.WriteHeader(http.StatusNoContent)
 				default:
@@ -56,6 +77,17 @@ func HandleUsers(app *AppCtx) http.HandlerFunc {
 				}
 			})(w, r)
 
+		case "followers":
+			handleFollowList(app, w, r, userId, true)
+
+		case "following":
+			handleFollowList(app, w, r, userId, false)
+
+		case "block":
+			WithAuth(app, func(w http.ResponseWriter, r *http.Request) {
+				handleBlock(app, w, r, currentUID(r), userId)
+			})(w, r)
+
 		default:
 			http.NotFound(w, r)
 		}
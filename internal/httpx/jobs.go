@@ -0,0 +1,90 @@
+package httpx
+
+import (
+	"log"
+	"time"
+
+	"local.dev/socialdemo-backend/internal/config"
+)
+
+// 背景工作：定期清掉超過復原期限的 boards（連同貼文、留言、按讚、上傳圖片）
+// main.go 以 goroutine 啟動：go httpx.RunBoardPurge(app, time.Hour)
+func RunBoardPurge(app *AppCtx, every time.Duration) {
+	purgeBoardsOnce(app)
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for range t.C {
+		purgeBoardsOnce(app)
+	}
+}
+
+func purgeBoardsOnce(app *AppCtx) {
+	boardIDs, media := app.Store.PurgeExpiredBoards(time.Now().UTC(), config.BoardRestoreWindow())
+	if len(boardIDs) == 0 {
+		return
+	}
+	for _, url := range media {
+		removeUpload(app, url)
+	}
+	app.Store.SaveBoards(app.Paths.BoardsFile)
+	app.Store.SavePosts(app.Paths.PostsFile)
+	app.Store.SaveLikes(app.Paths.LikesFile)
+	log.Printf("[board-purge] removed boards=%v media=%d", boardIDs, len(media))
+}
+
+// 背景工作：定期清除緩衝期已過的刪除帳號申請
+// main.go 以 goroutine 啟動：go httpx.RunAccountErasure(app, time.Hour)
+func RunAccountErasure(app *AppCtx, every time.Duration) {
+	eraseDueAccountsOnce(app)
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for range t.C {
+		eraseDueAccountsOnce(app)
+	}
+}
+
+func eraseDueAccountsOnce(app *AppCtx) {
+	for _, uid := range app.Store.DueDeletions(time.Now().UTC()) {
+		if d, ok := app.Store.GetDeletion(uid); ok {
+			eraseAccount(app, uid, d)
+		}
+	}
+}
+
+// 背景工作：定期刪掉過期的個人資料匯出檔
+// main.go 以 goroutine 啟動：go httpx.RunExportCleanup(app, time.Hour)
+func RunExportCleanup(app *AppCtx, every time.Duration) {
+	cleanupExportsOnce(app)
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for range t.C {
+		cleanupExportsOnce(app)
+	}
+}
+
+func cleanupExportsOnce(app *AppCtx) {
+	ids := app.Store.DropExports(time.Now().UTC(), "")
+	if len(ids) == 0 {
+		return
+	}
+	removeExports(app, ids)
+	app.Store.SaveExports(app.Paths.ExportsFile)
+	log.Printf("[export-cleanup] removed exports=%v", ids)
+}
+
+// 背景工作：定期重算每位使用者的推薦追蹤，結果快取在 Store
+// main.go 以 goroutine 啟動：go httpx.RunSuggestions(app, 15*time.Minute)
+func RunSuggestions(app *AppCtx, every time.Duration) {
+	refreshSuggestionsOnce(app)
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for range t.C {
+		refreshSuggestionsOnce(app)
+	}
+}
+
+func refreshSuggestionsOnce(app *AppCtx) {
+	start := time.Now()
+	n := app.Store.RefreshSuggestions()
+	log.Printf("[suggestions] refreshed users=%d took=%s", n, time.Since(start).Round(time.Millisecond))
+}
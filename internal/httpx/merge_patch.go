@@ -0,0 +1,51 @@
+package httpx
+
+import (
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+)
+
+// ===== JSON Merge Patch（RFC 7396）=====
+
+// patch 是物件時逐一合併：值為 null 代表刪除該欄位，其餘遞迴合併；
+// 不是物件時整個取代 target。
+func mergePatch(target, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = map[string]any{}
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+			continue
+		}
+		t[k] = mergePatch(t[k], v)
+	}
+	return t
+}
+
+// 讀 merge patch body（application/merge-patch+json；舊用戶端送 application/json 也照收），
+// 內容必須是 JSON 物件。JSON Patch（RFC 6902）的陣列格式不支援。
+func readMergePatch(w http.ResponseWriter, r *http.Request) (map[string]any, bool) {
+	if mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mt == "application/json-patch+json" {
+		http.Error(w, "use application/merge-patch+json", http.StatusUnsupportedMediaType)
+		return nil, false
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxProfileBody))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return nil, false
+	}
+	var patch map[string]any
+	if err := json.Unmarshal(body, &patch); err != nil || patch == nil {
+		http.Error(w, "merge patch must be a JSON object", http.StatusBadRequest)
+		return nil, false
+	}
+	return patch, true
+}
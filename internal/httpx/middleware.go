@@ -14,6 +14,7 @@ import (
 
 	"firebase.google.com/go/v4/auth"
 	"local.dev/socialdemo-backend/internal/config"
+	"local.dev/socialdemo-backend/internal/realtime"
 	"local.dev/socialdemo-backend/internal/store"
 )
 
@@ -25,6 +26,7 @@ type AppCtx struct {
 	Store      *store.Store
 	AuthClient *auth.Client
 	Paths      config.Paths
+	Hub        *realtime.Hub // 即時事件（/stream）
 }
 
 // === 共用：把 email/uid 正規化成「身分鍵」 ===
@@ -111,7 +113,7 @@ func WithAuth(app *AppCtx, next http.HandlerFunc) http.HandlerFunc {
 		// 免驗證模式：Debug > Bearer(passthrough 只撈 email/uid) > X-Auth-Uid > Cookie
 		if config.NoAuth() {
 			authz := r.Header.Get("Authorization")
-			var key string
+			var key, email, uid string
 			switch {
 			case strings.HasPrefix(authz, "Debug "):
 				// Debug 直接把字串當 key（你可放 email 或自定 uid）
@@ -120,7 +122,7 @@ func WithAuth(app *AppCtx, next http.HandlerFunc) http.HandlerFunc {
 					key = strings.ToLower(key) // Debug 也幫你小寫 email
 				}
 			case strings.HasPrefix(authz, "Bearer "):
-				email, uid := devClaimsFromBearer(authz)
+				email, uid = devClaimsFromBearer(authz)
 				key = pickKey(email, uid)
 			}
 			// ★ 後援：若沒有 Authorization，就改用前端送來的冗餘身分
@@ -132,6 +134,8 @@ func WithAuth(app *AppCtx, next http.HandlerFunc) http.HandlerFunc {
 			if key == "" {
 				key = devUIDFromCookie(w, r)
 			}
+			key = accountFor(app, email, uid, key)
+			ensureAccount(app, key)
 			ctx := context.WithValue(r.Context(), uidKey, key)
 			next(w, r.WithContext(ctx))
 			return
@@ -154,17 +158,65 @@ func WithAuth(app *AppCtx, next http.HandlerFunc) http.HandlerFunc {
 		if em, ok := tok.Claims["email"].(string); ok {
 			email = em
 		}
-		key := pickKey(email, tok.UID)
+		key := accountFor(app, email, tok.UID, pickKey(email, tok.UID))
+		ensureAccount(app, key)
 
 		ctx := context.WithValue(r.Context(), uidKey, key)
 		next(w, r.WithContext(ctx))
 	}
 }
 
+// 身分鍵 → 主帳號。同時帶 email 與 UID 的登入（Firebase token）會自動把 UID 連到 email 帳號，
+// 之後不帶 email 的登入方式（或開發用的 X-Auth-Uid，會轉小寫）也進到同一個帳號
+func accountFor(app *AppCtx, email, uid, key string) string {
+	if email != "" && uid != "" && key != uid {
+		linked := app.Store.LinkIdentity(uid, key)
+		if lower := strings.ToLower(uid); lower != uid && app.Store.LinkIdentity(lower, key) {
+			linked = true
+		}
+		if linked {
+			app.Store.SaveIdentities(app.Paths.IdentitiesFile)
+		}
+	}
+	return app.Store.CanonicalAccount(key)
+}
+
+// 第一次看到這個身分鍵時配一個 PublicID（對外只用它）
+func ensureAccount(app *AppCtx, key string) {
+	if _, created := app.Store.EnsurePublicID(key); created {
+		app.Store.SaveProfiles(app.Paths.ProfilesFile)
+	}
+}
+
 // 非強制驗證：給 likedByMe 等 viewer 用（同樣回「身分鍵」）
 func tryViewerUID(app *AppCtx, r *http.Request) string {
+	if k := viewerKey(app, r); k != "" {
+		return app.Store.CanonicalAccount(k)
+	}
+	return ""
+}
+
+func viewerKey(app *AppCtx, r *http.Request) string {
+	if config.NoAuth() {
+		if k := keyFromAuthorization(app, r, r.Header.Get("Authorization")); k != "" {
+			return k
+		}
+		// ★ 後援：讀取冗餘身分
+		if xu := strings.TrimSpace(r.Header.Get("X-Auth-Uid")); xu != "" {
+			return strings.ToLower(xu)
+		}
+		if c, err := r.Cookie(devUIDCookie); err == nil && c.Value != "" {
+			return c.Value
+		}
+		return ""
+	}
+	return keyFromAuthorization(app, r, r.Header.Get("Authorization"))
+}
+
+// 從一個 Authorization 值取出身分鍵（不換成主帳號）；也用來驗證「要合併的另一個帳號」確實是本人的。
+// 開發模式接受 Debug 與不驗簽的 Bearer；正式模式只接受驗證過的 Firebase ID token
+func keyFromAuthorization(app *AppCtx, r *http.Request, authz string) string {
 	if config.NoAuth() {
-		authz := r.Header.Get("Authorization")
 		if strings.HasPrefix(authz, "Debug ") {
 			k := strings.TrimSpace(strings.TrimPrefix(authz, "Debug "))
 			if strings.Contains(k, "@") {
@@ -174,20 +226,10 @@ func tryViewerUID(app *AppCtx, r *http.Request) string {
 		}
 		if strings.HasPrefix(authz, "Bearer ") {
 			email, uid := devClaimsFromBearer(authz)
-			if k := pickKey(email, uid); k != "" {
-				return k
-			}
-		}
-		// ★ 後援：讀取冗餘身分
-		if xu := strings.TrimSpace(r.Header.Get("X-Auth-Uid")); xu != "" {
-			return strings.ToLower(xu)
-		}
-		if c, err := r.Cookie(devUIDCookie); err == nil && c.Value != "" {
-			return c.Value
+			return pickKey(email, uid)
 		}
 		return ""
 	}
-	authz := r.Header.Get("Authorization")
 	if strings.HasPrefix(authz, "Bearer ") && app.AuthClient != nil {
 		idToken := strings.TrimSpace(strings.TrimPrefix(authz, "Bearer "))
 		if tok, err := app.AuthClient.VerifyIDToken(r.Context(), idToken); err == nil {
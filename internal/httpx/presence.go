@@ -0,0 +1,72 @@
+package httpx
+
+import (
+	"net/http"
+
+	"local.dev/socialdemo-backend/internal/realtime"
+)
+
+// 套用隱私設定後的上線狀態（HideLastSeen → 不給 lastSeen）
+func presenceFor(app *AppCtx, uid string) realtime.PresenceInfo {
+	info := app.Hub.Presence(uid)
+	if prof, ok := app.Store.GetProfile(uid); ok && prof.HideLastSeen {
+		info.LastSeen = ""
+	}
+	return info
+}
+
+// 通知所有聊天對象：uid 上線 / 離線
+func publishPresence(app *AppCtx, uid string) {
+	app.Hub.PublishMany(app.Store.ConversationPartners(uid), publicEvent(app, realtime.Event{
+		Type:   realtime.EventPresence,
+		UserID: uid,
+		Data:   presenceFor(app, uid),
+	}))
+}
+
+// 開始一條串流連線；第一條連線代表「上線」
+func joinStream(app *AppCtx, uid string) *realtime.Subscriber {
+	sub := app.Hub.Subscribe(uid)
+	if app.Hub.Connections(uid) == 1 {
+		publishPresence(app, uid)
+	}
+	return sub
+}
+
+// 結束串流連線；最後一條連線斷開代表「離線」
+func leaveStream(app *AppCtx, sub *realtime.Subscriber) {
+	app.Hub.Unsubscribe(sub)
+	if app.Hub.Connections(sub.UserID) == 0 {
+		publishPresence(app, sub.UserID)
+	}
+}
+
+// GET /conversations/{id}/presence → 成員上線狀態 + 正在輸入的人
+// 跟自己有封鎖關係（任一方）的成員一律顯示為離線、不列入輸入中
+func handleConversationPresence(app *AppCtx, w http.ResponseWriter, _ *http.Request, uid, convID string) {
+	conv, ok := app.Store.GetConversation(convID)
+	if !ok || !containsString(conv.MemberIDs, uid) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "conversation not found"})
+		return
+	}
+	members := make([]realtime.PresenceInfo, 0, len(conv.MemberIDs))
+	for _, id := range conv.MemberIDs {
+		var info realtime.PresenceInfo
+		if id == uid || !app.Store.IsBlockedEither(uid, id) {
+			info = presenceFor(app, id)
+		}
+		info.UserID = pubID(app, id)
+		members = append(members, info)
+	}
+	typing := make([]string, 0)
+	for _, id := range app.Hub.TypingIn(convID) {
+		if id == uid || !app.Store.IsBlockedEither(uid, id) {
+			typing = append(typing, id)
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"conversationId": convID,
+		"members":        members,
+		"typing":         pubIDs(app, typing),
+	})
+}
@@ -0,0 +1,47 @@
+package httpx
+
+import (
+	"local.dev/socialdemo-backend/internal/models"
+)
+
+// ===== 給別人看的 Profile =====
+// 本人看自己時原樣回傳；其他人只看得到 Show* 打開的社群帳號，生日預設不含年份。
+// 本人打開 MutualsSeeContacts 時，互相追蹤的人可以看到全部社群帳號與完整生日。
+func publicProfile(app *AppCtx, p models.Profile, viewer string) models.Profile {
+	self := viewer != "" && viewer == p.ID
+	p = withFollowStats(app, p, viewer)
+	if self {
+		return p
+	}
+	trusted := p.MutualsSeeContacts && p.Mutual
+	if !p.ShowInstagram && !trusted {
+		p.Instagram = nil
+	}
+	if !p.ShowFacebook && !trusted {
+		p.Facebook = nil
+	}
+	if !p.ShowLine && !trusted {
+		p.LineId = nil
+	}
+	p.Birthday = publicBirthday(p.Birthday, p.BirthdayVisibility, trusted)
+
+	// 隱私設定本身只給本人看
+	p.BirthdayVisibility = ""
+	p.MutualsSeeContacts = false
+	p.HandleHistory = nil
+	return p
+}
+
+// yyyy-MM-dd → 依設定回傳完整日期、"--MM-dd"（ISO 8601 不含年份）或空字串
+func publicBirthday(birthday, visibility string, trusted bool) string {
+	if birthday == "" || visibility == models.BirthdayHidden {
+		return ""
+	}
+	if visibility == models.BirthdayFull || trusted {
+		return birthday
+	}
+	if len(birthday) == len("2006-01-02") {
+		return "--" + birthday[5:]
+	}
+	return ""
+}
@@ -0,0 +1,212 @@
+package httpx
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"local.dev/socialdemo-backend/internal/config"
+	"local.dev/socialdemo-backend/internal/models"
+	"local.dev/socialdemo-backend/internal/msgtypes"
+)
+
+// ===== PATCH /me：欄位清理與驗證 =====
+// 先清理（去頭尾空白、拿掉控制字元、社群帳號去掉開頭的 @），再逐欄檢查；
+// 有任何一欄不合格就整筆 422，列出每個欄位的原因。
+// PATCH /me 是 JSON Merge Patch（merge_patch.go），先合併到目前的 Profile 再驗證。
+
+const (
+	maxProfileBody  = 64 << 10 // PATCH /me body 上限
+	maxNameLen      = 50
+	maxNicknameLen  = 30
+	maxAvatarURLLen = 2048
+	minBirthYear    = 1900
+)
+
+var (
+	instagramRe = regexp.MustCompile(`^[A-Za-z0-9._]{1,30}$`)
+	facebookRe  = regexp.MustCompile(`^[A-Za-z0-9.]{5,50}$`) // 使用者名稱或數字 ID
+	lineIDRe    = regexp.MustCompile(`^[a-z0-9._-]{4,20}$`)
+)
+
+// 拿掉控制字元（換行、tab、零寬等格式字元也算），並把連續空白縮成一個
+func cleanText(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range strings.TrimSpace(s) {
+		if unicode.IsControl(r) || unicode.Is(unicode.Cf, r) {
+			continue
+		}
+		if unicode.IsSpace(r) {
+			if !space {
+				b.WriteRune(' ')
+			}
+			space = true
+			continue
+		}
+		space = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func cleanHandle(s string) string {
+	return strings.TrimPrefix(cleanText(s), "@")
+}
+
+// 就地清理 p，回傳不合格的欄位（nil = 全部通過）
+func validateProfile(p *models.Profile) []msgtypes.FieldError {
+	var errs []msgtypes.FieldError
+	bad := func(field, reason string) {
+		errs = append(errs, msgtypes.FieldError{Field: field, Reason: reason})
+	}
+
+	p.Name = cleanText(p.Name)
+	switch {
+	case p.Name == "":
+		bad("name", "required")
+	case utf8.RuneCountInString(p.Name) > maxNameLen:
+		bad("name", "too long")
+	}
+
+	if p.Nickname != nil {
+		v := cleanText(*p.Nickname)
+		p.Nickname = &v
+		if utf8.RuneCountInString(v) > maxNicknameLen {
+			bad("nickname", "too long")
+		}
+	}
+
+	if p.AvatarURL != nil {
+		v := strings.TrimSpace(*p.AvatarURL)
+		p.AvatarURL = &v
+		if v != "" {
+			if reason := checkAvatarURL(v); reason != "" {
+				bad("avatarUrl", reason)
+			}
+		}
+	}
+
+	checkSocial := func(field string, v **string, re *regexp.Regexp, lower bool) {
+		if *v == nil {
+			return
+		}
+		s := cleanHandle(**v)
+		if lower {
+			s = strings.ToLower(s)
+		}
+		*v = &s
+		if s != "" && !re.MatchString(s) {
+			bad(field, "invalid format")
+		}
+	}
+	checkSocial("instagram", &p.Instagram, instagramRe, false)
+	checkSocial("facebook", &p.Facebook, facebookRe, false)
+	checkSocial("lineId", &p.LineId, lineIDRe, true)
+
+	p.Birthday = strings.TrimSpace(p.Birthday)
+	if p.Birthday != "" {
+		t, err := time.Parse("2006-01-02", p.Birthday)
+		switch {
+		case err != nil:
+			bad("birthday", "must be yyyy-MM-dd")
+		case t.Year() < minBirthYear || t.After(time.Now().UTC()):
+			bad("birthday", "out of range")
+		}
+	}
+
+	switch p.BirthdayVisibility {
+	case "", models.BirthdayMonthDay, models.BirthdayFull, models.BirthdayHidden:
+	default:
+		bad("birthdayVisibility", "must be one of monthDay, full, hidden")
+	}
+	return errs
+}
+
+// 站內上傳（/uploads/xxx）或白名單內的 https 來源
+func checkAvatarURL(v string) string {
+	if len(v) > maxAvatarURLLen {
+		return "too long"
+	}
+	if strings.HasPrefix(v, "/uploads/") {
+		if strings.Contains(v, "..") || strings.ContainsAny(v[len("/uploads/"):], "/\\") {
+			return "invalid upload path"
+		}
+		return ""
+	}
+	u, err := url.Parse(v)
+	if err != nil || u.Scheme != "https" || u.Host == "" || u.User != nil {
+		return "must be an https URL or an uploaded file"
+	}
+	origin := "https://" + strings.ToLower(u.Host)
+	for _, o := range config.AvatarOrigins() {
+		if origin == o {
+			return ""
+		}
+	}
+	return "origin not allowed"
+}
+
+// 不能透過 PATCH /me 修改的欄位：伺服器管理（handle 走 /me/handle、徽章由管理員設定）或依 viewer 計算
+var readOnlyProfileFields = []string{
+	"id", "publicId", "handle", "handleHistory", "verifiedBadge",
+	"followerCount", "followingCount", "followedByMe", "followsMe", "mutual", "followRequested",
+}
+
+// 把 merge patch 套到目前的 Profile，清理後只檢查這次有動到的欄位
+// （舊資料不合格的欄位不會擋住其他欄位的更新）
+func applyProfilePatch(cur models.Profile, patch map[string]any) (models.Profile, error) {
+	for _, k := range readOnlyProfileFields {
+		delete(patch, k)
+	}
+	raw, err := json.Marshal(cur)
+	if err != nil {
+		return cur, err
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return cur, err
+	}
+	merged, err := json.Marshal(mergePatch(doc, patch))
+	if err != nil {
+		return cur, err
+	}
+
+	var next models.Profile
+	if err := json.Unmarshal(merged, &next); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return cur, &msgtypes.ValidationError{Fields: []msgtypes.FieldError{{Field: typeErr.Field, Reason: "wrong type"}}}
+		}
+		return cur, err
+	}
+
+	var errs []msgtypes.FieldError
+	for _, fe := range validateProfile(&next) {
+		if _, touched := patch[fe.Field]; touched {
+			errs = append(errs, fe)
+		}
+	}
+	if len(errs) > 0 {
+		return cur, &msgtypes.ValidationError{Fields: errs}
+	}
+	return next, nil
+}
+
+func writeProfileErrors(w http.ResponseWriter, err error) {
+	var verr *msgtypes.ValidationError
+	if errors.As(err, &verr) {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]any{
+			"error":  "invalid profile",
+			"fields": verr.Fields,
+		})
+		return
+	}
+	http.Error(w, err.Error(), http.StatusBadRequest)
+}
@@ -0,0 +1,169 @@
+package httpx
+
+import (
+	"net/http"
+	"strings"
+
+	"local.dev/socialdemo-backend/internal/models"
+	"local.dev/socialdemo-backend/internal/realtime"
+)
+
+// ===== 對外的使用者 ID =====
+// 後端一律用身分鍵（email 小寫 / Firebase UID）當 key；回應前換成 PublicID，
+// 請求裡的使用者參照（路徑、memberIds、userId…）則先用 resolveUser 換回身分鍵。
+// 換 PublicID 是唯讀的：已不存在的帳號換出來是空字串，不會因此建立 profile。
+
+func pubID(app *AppCtx, uid string) string {
+	if uid == "" {
+		return ""
+	}
+	return app.Store.PublicID(uid)
+}
+
+func pubIDs(app *AppCtx, ids []string) []string {
+	if ids == nil {
+		return nil
+	}
+	out := make([]string, len(ids))
+	for i, id := range ids {
+		out[i] = pubID(app, id)
+	}
+	return out
+}
+
+// "@handle" / PublicID → 身分鍵；身分鍵本身只接受請求者自己的（見 Store.ResolveUserRef）
+func resolveUser(app *AppCtx, r *http.Request, ref string) (string, bool) {
+	if uid, ok := app.Store.ResolveUserRef(ref, ""); ok {
+		return uid, true
+	}
+	// 不是 handle / PublicID：這時才去認請求者（可能要驗 token）
+	return app.Store.ResolveUserRef(ref, tryViewerUID(app, r))
+}
+
+// 找不到使用者時回 404
+func resolveUserOr404(app *AppCtx, w http.ResponseWriter, r *http.Request, ref string) (string, bool) {
+	uid, ok := resolveUser(app, r, ref)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "user not found"})
+	}
+	return uid, ok
+}
+
+// 整批解析；空白的略過，任何一個找不到就回 404
+func resolveUsersOr404(app *AppCtx, w http.ResponseWriter, r *http.Request, refs []string) ([]string, bool) {
+	out := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		if strings.TrimSpace(ref) == "" {
+			continue
+		}
+		uid, ok := resolveUserOr404(app, w, r, ref)
+		if !ok {
+			return nil, false
+		}
+		out = append(out, uid)
+	}
+	return out, true
+}
+
+// 訊息：寄件者、已讀名單，以及系統訊息內容裡的使用者
+func publicMessage(app *AppCtx, m models.Message) models.Message {
+	m.SenderID = pubID(app, m.SenderID)
+	m.SeenBy = pubIDs(app, m.SeenBy)
+	m.HiddenFor = nil
+	if m.Type == "system" && len(m.ContentJson) > 0 {
+		c := make(map[string]any, len(m.ContentJson))
+		for k, v := range m.ContentJson {
+			switch val := v.(type) {
+			case string:
+				if strings.HasSuffix(k, "Id") {
+					v = pubID(app, val)
+				}
+			case []string:
+				if strings.HasSuffix(k, "Ids") {
+					v = pubIDs(app, val)
+				}
+			case []any:
+				if strings.HasSuffix(k, "Ids") {
+					ids := make([]any, len(val))
+					for i, x := range val {
+						if s, ok := x.(string); ok {
+							ids[i] = pubID(app, s)
+						} else {
+							ids[i] = x
+						}
+					}
+					v = ids
+				}
+			}
+			c[k] = v
+		}
+		m.ContentJson = c
+	}
+	return m
+}
+
+func publicMessages(app *AppCtx, ms []models.Message) []models.Message {
+	out := make([]models.Message, len(ms))
+	for i, m := range ms {
+		out[i] = publicMessage(app, m)
+	}
+	return out
+}
+
+// 對話：成員、管理員、已讀游標、離開的成員；DirectKey 是由身分鍵組成的，不回傳
+func publicConversation(app *AppCtx, c models.Conversation) models.Conversation {
+	c.MemberIDs = pubIDs(app, c.MemberIDs)
+	c.AdminIDs = pubIDs(app, c.AdminIDs)
+	c.PendingFor = pubIDs(app, c.PendingFor)
+	c.DeclinedBy = pubIDs(app, c.DeclinedBy)
+	c.CreatedBy = pubID(app, c.CreatedBy)
+	c.DirectKey = ""
+	if c.ReadCursors != nil {
+		cursors := make(map[string]models.ReadCursor, len(c.ReadCursors))
+		for id, cur := range c.ReadCursors {
+			if pid := pubID(app, id); pid != "" {
+				cursors[pid] = cur
+			}
+		}
+		c.ReadCursors = cursors
+	}
+	if c.FormerMembers != nil {
+		former := make(map[string]models.FormerMember, len(c.FormerMembers))
+		for id, fm := range c.FormerMembers {
+			if pid := pubID(app, id); pid != "" {
+				former[pid] = fm
+			}
+		}
+		c.FormerMembers = former
+	}
+	return c
+}
+
+func publicBoard(app *AppCtx, b models.Board) models.Board {
+	b.OwnerID = pubID(app, b.OwnerID)
+	b.ModeratorIDs = pubIDs(app, b.ModeratorIDs)
+	return b
+}
+
+func publicBoards(app *AppCtx, bs []models.Board) []models.Board {
+	out := make([]models.Board, len(bs))
+	for i, b := range bs {
+		out[i] = publicBoard(app, b)
+	}
+	return out
+}
+
+// 即時事件：觸發者，以及 data 裡常見的型別
+func publicEvent(app *AppCtx, ev realtime.Event) realtime.Event {
+	ev.UserID = pubID(app, ev.UserID)
+	switch d := ev.Data.(type) {
+	case models.Message:
+		ev.Data = publicMessage(app, d)
+	case models.Conversation:
+		ev.Data = publicConversation(app, d)
+	case realtime.PresenceInfo:
+		d.UserID = pubID(app, d.UserID)
+		ev.Data = d
+	}
+	return ev
+}
@@ -0,0 +1,222 @@
+package httpx
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"local.dev/socialdemo-backend/internal/config"
+	"local.dev/socialdemo-backend/internal/models"
+	"local.dev/socialdemo-backend/internal/realtime"
+)
+
+// GET /stream
+//
+// 即時事件串流：帶 Upgrade: websocket 走 WebSocket，否則走 SSE（text/event-stream）。
+// 瀏覽器的 EventSource / WebSocket 無法自訂 header，所以允許用 ?access_token= 代替 Authorization；
+// 一定要帶其中一種（不吃 Cookie / X-Auth-Uid，避免別的網站借用瀏覽器的登入狀態連進來）。
+// WebSocket 另外檢查 Origin（STREAM_ORIGINS）。
+//
+// 伺服端推送：message.new / message.edit / message.delete / read / typing / presence
+// 用戶端（僅 WebSocket）可送：{"type":"typing","conversationId":"c_xxx","data":{"typing":true}}
+// SSE 用戶端改打 POST /conversations/{id}/typing
+func HandleStream(app *AppCtx) http.HandlerFunc {
+	inner := WithAuth(app, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		uid := currentUID(r)
+		if realtime.IsWebSocketRequest(r) {
+			serveWebSocket(app, w, r, uid)
+			return
+		}
+		serveSSE(app, w, r, uid)
+	})
+	return func(w http.ResponseWriter, r *http.Request) {
+		// token 搬到 header 後從 URL 拿掉，之後的 log / 錯誤訊息不會帶到
+		if q := r.URL.Query(); q.Has("access_token") {
+			if tok := strings.TrimSpace(q.Get("access_token")); tok != "" && r.Header.Get("Authorization") == "" {
+				r.Header.Set("Authorization", "Bearer "+tok)
+			}
+			q.Del("access_token")
+			r.URL.RawQuery = q.Encode()
+			r.RequestURI = r.URL.RequestURI()
+		}
+		if r.Header.Get("Authorization") == "" {
+			http.Error(w, "missing bearer token", http.StatusUnauthorized)
+			return
+		}
+		inner(w, r)
+	}
+}
+
+const streamHeartbeat = 25 * time.Second
+
+func serveSSE(app *AppCtx, w http.ResponseWriter, r *http.Request, uid string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // 避免反向代理緩衝
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	sub := joinStream(app, uid)
+	defer leaveStream(app, sub)
+
+	tick := time.NewTicker(streamHeartbeat)
+	defer tick.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-tick.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case ev := <-sub.C:
+			b, err := json.Marshal(ev)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, b); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func serveWebSocket(app *AppCtx, w http.ResponseWriter, r *http.Request, uid string) {
+	ws, err := realtime.UpgradeWebSocket(w, r, config.StreamOrigins())
+	if err != nil {
+		log.Printf("[stream] websocket upgrade uid=%s: %v", uid, err)
+		return
+	}
+	defer ws.Close()
+
+	sub := joinStream(app, uid)
+	defer leaveStream(app, sub)
+
+	// 讀取端：處理用戶端送來的事件，連線斷掉就通知寫入端結束
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			b, err := ws.ReadMessage()
+			if err != nil {
+				return
+			}
+			var in realtime.Event
+			if err := json.Unmarshal(b, &in); err != nil {
+				continue
+			}
+			handleClientEvent(app, uid, in)
+		}
+	}()
+
+	tick := time.NewTicker(streamHeartbeat)
+	defer tick.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-tick.C:
+			if err := ws.WritePing(); err != nil {
+				return
+			}
+		case ev := <-sub.C:
+			b, err := json.Marshal(ev)
+			if err != nil {
+				continue
+			}
+			if err := ws.WriteText(b); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// 用戶端經由 WebSocket 送上來的事件（目前只接受 typing）
+func handleClientEvent(app *AppCtx, uid string, in realtime.Event) {
+	switch in.Type {
+	case realtime.EventTyping:
+		conv, ok := app.Store.GetConversation(in.ConversationID)
+		if !ok || !containsString(conv.MemberIDs, uid) {
+			return
+		}
+		typing := true
+		if m, ok := in.Data.(map[string]any); ok {
+			if v, ok := m["typing"].(bool); ok {
+				typing = v
+			}
+		}
+		publishTyping(app, conv, uid, typing)
+	}
+}
+
+// POST /conversations/{id}/typing  body（可省略）：{"typing": true}
+func handleTyping(app *AppCtx, w http.ResponseWriter, r *http.Request, uid, convID string) {
+	in := struct {
+		Typing *bool `json:"typing"`
+	}{}
+	if r.ContentLength != 0 {
+		_ = json.NewDecoder(r.Body).Decode(&in)
+	}
+	conv, ok := app.Store.GetConversation(convID)
+	if !ok || !containsString(conv.MemberIDs, uid) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "conversation not found"})
+		return
+	}
+	typing := in.Typing == nil || *in.Typing
+	publishTyping(app, conv, uid, typing)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func publishTyping(app *AppCtx, conv models.Conversation, uid string, typing bool) {
+	app.Hub.SetTyping(conv.ID, uid, typing) // 記在記憶體，TypingTTL 後自動過期
+	others := make([]string, 0, len(conv.MemberIDs))
+	for _, id := range conv.MemberIDs {
+		// 封鎖關係的另一方（任一方向）收不到輸入中，跟 presence 一致
+		if id != uid && !app.Store.IsBlockedEither(uid, id) {
+			others = append(others, id)
+		}
+	}
+	app.Hub.PublishMany(others, publicEvent(app, realtime.Event{
+		Type:           realtime.EventTyping,
+		ConversationID: conv.ID,
+		UserID:         uid,
+		Data: map[string]any{
+			"typing":    typing,
+			"expiresIn": int(realtime.TypingTTL / time.Second),
+		},
+	}))
+}
+
+// 對話成員全部推送（包含自己，讓其他裝置同步）；靜音中或還沒接受請求的成員收到 silent 事件，拒絕請求的人不推送
+func publishToMembers(app *AppCtx, conv models.Conversation, ev realtime.Event) {
+	if ev.ConversationID == "" {
+		ev.ConversationID = conv.ID
+	}
+	if ev.At == "" {
+		ev.At = time.Now().UTC().Format(time.RFC3339)
+	}
+	for _, id := range conv.MemberIDs {
+		if containsString(conv.DeclinedBy, id) {
+			continue
+		}
+		e := ev
+		e.Silent = id != ev.UserID && (containsString(conv.PendingFor, id) || app.Store.IsConversationMuted(id, conv.ID))
+		app.Hub.Publish(id, publicEvent(app, e))
+	}
+}
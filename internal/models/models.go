@@ -1,9 +1,10 @@
 package models
 
 type User struct {
-	ID        string  `json:"id"`
-	Name      string  `json:"name"`
-	AvatarURL *string `json:"avatarUrl,omitempty"`
+	ID            string  `json:"id"`
+	Name          string  `json:"name"`
+	AvatarURL     *string `json:"avatarUrl,omitempty"`
+	VerifiedBadge string  `json:"verifiedBadge,omitempty"` // 由 Profile 帶出，前端顯示認證徽章
 }
 
 type Comment struct {
@@ -40,6 +41,59 @@ type Profile struct {
 	ShowInstagram bool    `json:"showInstagram"`
 	ShowFacebook  bool    `json:"showFacebook"`
 	ShowLine      bool    `json:"showLine"`
+
+	// 🔻 新增：生日給別人看到的程度（見 Birthday* 常數；空字串 = 只顯示月日）
+	BirthdayVisibility string `json:"birthdayVisibility,omitempty"`
+	// 🔻 新增：互相追蹤的人也看得到沒公開的社群帳號與完整生日
+	MutualsSeeContacts bool `json:"mutualsSeeContacts"`
+
+	// 🔻 新增：隱私設定 — 不讓別人看到「最後上線時間」
+	HideLastSeen bool `json:"hideLastSeen"`
+
+	// 🔻 新增：私人帳號 — 追蹤要先經過本人核准，貼文只給已核准的粉絲看
+	Private bool `json:"private"`
+
+	// 🔻 新增：認證徽章（"verified" / "official" / "artist" / "staff"），只能由管理員設定
+	VerifiedBadge string `json:"verifiedBadge,omitempty"`
+
+	// 🔻 新增：追蹤統計與 viewer 的關係（回應時依 friends 計算，不存檔）
+	FollowerCount   int  `json:"followerCount"`
+	FollowingCount  int  `json:"followingCount"`
+	FollowedByMe    bool `json:"followedByMe,omitempty"`    // 我追蹤他
+	FollowsMe       bool `json:"followsMe,omitempty"`       // 他追蹤我
+	Mutual          bool `json:"mutual,omitempty"`          // 互相追蹤
+	FollowRequested bool `json:"followRequested,omitempty"` // 我送出的追蹤請求還在等對方核准
+
+	// 🔻 新增：公開識別 — 回應裡用 PublicID 取代 email / Firebase UID（身分鍵只留在後端）
+	PublicID      string         `json:"publicId,omitempty"`
+	Handle        string         `json:"handle,omitempty"`        // 不含 @、全小寫、全站唯一；用 PUT /me/handle 修改
+	HandleHistory []HandleChange `json:"handleHistory,omitempty"` // 用過的 handle（只給本人看）
+}
+
+// 🔻 新增：換掉的 handle；釋出後保留一段時間只有原主人能拿回
+type HandleChange struct {
+	Handle     string `json:"handle"`
+	ReleasedAt string `json:"releasedAt"`
+}
+
+// 🔻 新增：Profile.BirthdayVisibility 的值
+const (
+	BirthdayMonthDay = "monthDay" // 預設：只顯示月日（"--MM-dd"）
+	BirthdayFull     = "full"     // 完整 yyyy-MM-dd
+	BirthdayHidden   = "hidden"   // 完全不顯示
+)
+
+// 🔻 新增：靜音名單（不封鎖，只是不想在 feed 看到）
+type MuteEntry struct {
+	Value     string `json:"value"`
+	ExpiresAt string `json:"expiresAt,omitempty"` // 空 = 永久
+}
+
+type MuteList struct {
+	Accounts  []MuteEntry `json:"accounts"`
+	Tags      []MuteEntry `json:"tags"`
+	Keywords  []MuteEntry `json:"keywords"` // 比對貼文內文與標籤（不分大小寫、全形半形）
+	UpdatedAt string      `json:"updatedAt,omitempty"`
 }
 
 type Board struct {
@@ -49,10 +103,13 @@ type Board struct {
 	OwnerID      string   `json:"ownerId"`
 	ModeratorIDs []string `json:"moderatorIds,omitempty"`
 	IsOfficial   bool     `json:"isOfficial,omitempty"`
+	IdolGroup    string   `json:"idolGroup,omitempty"` // 官方版對應的偶像團體（每團最多一個官方版）
 	IsPrivate    bool     `json:"isPrivate,omitempty"`
 	CreatedAt    string   `json:"createdAt"`
 	UpdatedAt    string   `json:"updatedAt"`
 	Deleted      bool     `json:"deleted,omitempty"`
+	DeletedAt    string   `json:"deletedAt,omitempty"` // 軟刪除時間
+	PurgeAt      string   `json:"purgeAt,omitempty"`   // 超過這個時間就無法復原，背景工作會連同貼文/媒體一起清掉
 }
 
 type Conversation struct {
@@ -62,16 +119,104 @@ type Conversation struct {
 	CreatedAt          string   `json:"createdAt"`
 	LastMessageAt      string   `json:"lastMessageAt,omitempty"`
 	LastMessagePreview string   `json:"lastMessagePreview,omitempty"`
+	LastMessageID      string   `json:"lastMessageId,omitempty"` // 🔻 新增：用來依語系重新產生預覽
+
+	// 🔻 新增：每位成員的已讀游標（memberId -> 讀到哪一則）
+	ReadCursors map[string]ReadCursor `json:"readCursors,omitempty"`
+	// 依 viewer 計算，不代表存檔內容
+	UnreadCount int `json:"unreadCount"`
+
+	// 🔻 新增：一對一 / 群組
+	Kind      string `json:"kind"`                // "direct"（一對一）/ "group"
+	DirectKey string `json:"directKey,omitempty"` // 一對一專用：排序後的成員 ID，同一組人只會有一個對話
+
+	// 🔻 新增：群組管理
+	CreatedBy     string                  `json:"createdBy,omitempty"`
+	AdminIDs      []string                `json:"adminIds,omitempty"`      // 群組管理員（可改名、加人、踢人、指派管理員）
+	FormerMembers map[string]FormerMember `json:"formerMembers,omitempty"` // 已離開 / 被移出的成員：只能讀到離開當下為止的訊息
+
+	// 🔻 新增：viewer 自己的設定（另存 conversation_settings.json，回應時才帶上）
+	Settings *ConversationSettings `json:"settings,omitempty"`
+
+	// 🔻 新增：訊息請求 — 由自己沒追蹤的人拉進來的對話，接受前放在「請求」收件匣
+	PendingFor []string `json:"pendingFor,omitempty"` // 還沒接受的成員：對方看不到他的已讀
+	DeclinedBy []string `json:"declinedBy,omitempty"` // 拒絕的成員（一對一）：對話不再出現在他的列表，只存檔不回傳
+	IsRequest  bool     `json:"isRequest,omitempty"`  // 依 viewer 計算：這個對話對我來說是請求
+}
+
+// 每位成員各自的對話設定
+type ConversationSettings struct {
+	MutedUntil string `json:"mutedUntil,omitempty"` // 靜音到這個時間（永久靜音 = ForeverMuted）
+	Archived   bool   `json:"archived"`
+	PinnedAt   string `json:"pinnedAt,omitempty"` // 置頂時間，越晚置頂排越前面
+	Nickname   string `json:"nickname,omitempty"` // 只有自己看得到的對話名稱
+	UpdatedAt  string `json:"updatedAt,omitempty"`
+
+	// 依上面欄位計算
+	Muted  bool `json:"muted"`
+	Pinned bool `json:"pinned"`
+}
+
+type FormerMember struct {
+	LeftAt        string `json:"leftAt"`
+	LastMessageID string `json:"lastMessageId"` // 看得到的最後一則（離開時插入的系統訊息）
+}
+
+type ReadCursor struct {
+	MessageID string `json:"messageId"`
+	MessageAt string `json:"messageAt"` // 該則訊息的 createdAt，用來比較先後
+	ReadAt    string `json:"readAt"`
 }
 
 type Message struct {
 	ID             string         `json:"id"`
 	ConversationID string         `json:"conversationId"`
 	SenderID       string         `json:"senderId"`
-	Type           string         `json:"type"` // "text" / "miniCard" / "album"
+	Type           string         `json:"type"` // "text" / "image" / "miniCard" / "album" / "tradeOffer" / "sticker" / "system"（見 internal/msgtypes）
 	Text           string         `json:"text,omitempty"`
 	ContentSchema  string         `json:"contentSchema,omitempty"`
 	ContentJson    map[string]any `json:"contentJson,omitempty"`
 	CreatedAt      string         `json:"createdAt"`
 	Deleted        bool           `json:"deleted,omitempty"`
+
+	// 群組聊天用：已讀到這則的成員（依 ReadCursors 計算，不存檔）
+	SeenBy []string `json:"seenBy,omitempty"`
+
+	// 🔻 新增：編輯 / 收回 / 只對自己刪除
+	EditedAt    string        `json:"editedAt,omitempty"`
+	EditHistory []MessageEdit `json:"editHistory,omitempty"` // 舊 → 新，保存每次編輯前的內容
+	DeletedAt   string        `json:"deletedAt,omitempty"`   // 收回時間（Deleted=true 時顯示「訊息已收回」）
+	HiddenFor   []string      `json:"hiddenFor,omitempty"`   // 「只對自己刪除」的成員，不回傳給前端
 }
+
+type MessageEdit struct {
+	Text     string `json:"text"`
+	EditedAt string `json:"editedAt"` // 這個版本被取代的時間
+}
+
+// 🔻 新增：刪除帳號申請。先拿確認碼，確認後排程在 PurgeAt 清除，期間內可以取消
+type AccountDeletion struct {
+	RequestedAt    string `json:"requestedAt"`
+	ConfirmToken   string `json:"confirmToken,omitempty"` // 還沒確認時才有
+	ConfirmExpires string `json:"confirmExpires,omitempty"`
+	ConfirmedAt    string `json:"confirmedAt,omitempty"`
+	PurgeAt        string `json:"purgeAt,omitempty"` // 確認後才有；到期由背景工作清除
+}
+
+// 🔻 新增：個人資料匯出（zip 另存 data/exports/<id>.zip）
+type DataExport struct {
+	ID          string `json:"id"`
+	OwnerID     string `json:"ownerId,omitempty"` // 申請人的身分鍵，不回傳給前端
+	Status      string `json:"status"`            // 見 Export* 常數
+	CreatedAt   string `json:"createdAt"`
+	CompletedAt string `json:"completedAt,omitempty"`
+	ExpiresAt   string `json:"expiresAt,omitempty"` // 過期後檔案刪除，要重新申請
+	Size        int64  `json:"size,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+const (
+	ExportPending = "pending"
+	ExportReady   = "ready"
+	ExportFailed  = "failed"
+)
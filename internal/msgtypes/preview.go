@@ -0,0 +1,84 @@
+package msgtypes
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"local.dev/socialdemo-backend/internal/models"
+)
+
+// 對話列表預覽最多顯示幾個字
+const previewMaxLen = 80
+
+// 預覽用的標籤：type -> [en, zh-TW]
+var typeLabels = map[string][2]string{
+	"image":      {"[Photo]", "[圖片]"},
+	"miniCard":   {"[Mini Card]", "[小卡]"},
+	"album":      {"[Album]", "[專輯]"},
+	"tradeOffer": {"[Trade offer]", "[交換提議]"},
+	"sticker":    {"[Sticker]", "[貼圖]"},
+}
+
+// 系統訊息：event -> [en, zh-TW]
+var systemLabels = map[string][2]string{
+	"member_added":   {"[Members added]", "[新增成員]"},
+	"member_removed": {"[Member removed]", "[成員被移出]"},
+	"member_left":    {"[Member left]", "[成員已離開]"},
+	"renamed":        {"[Group renamed]", "[群組已改名]"},
+	"admin_added":    {"[Admins changed]", "[管理員異動]"},
+	"admin_removed":  {"[Admins changed]", "[管理員異動]"},
+}
+
+func pick(locale string, label [2]string) string {
+	if strings.HasPrefix(strings.ToLower(locale), "zh") {
+		return label[1]
+	}
+	return label[0]
+}
+
+// 依 type 與語系產生預覽文字；locale 為 "en" / "zh-tw"（其他一律當英文）
+func Preview(m models.Message, locale string) string {
+	if m.Deleted {
+		return pick(locale, [2]string{"Message unsent", "訊息已收回"})
+	}
+	switch m.Type {
+	case "", "text":
+		return truncate(m.Text)
+	case "system":
+		ev, _ := m.ContentJson["event"].(string)
+		if label, ok := systemLabels[ev]; ok {
+			return pick(locale, label)
+		}
+		return pick(locale, [2]string{"[System]", "[系統訊息]"})
+	}
+
+	label, ok := typeLabels[m.Type]
+	if !ok {
+		return truncate(m.Text)
+	}
+	out := pick(locale, label)
+	// 帶上卡片名稱 / 專輯名稱，或使用者附加的文字
+	detail := ""
+	switch m.Type {
+	case "miniCard":
+		detail, _ = m.ContentJson["name"].(string)
+	case "album":
+		detail, _ = m.ContentJson["title"].(string)
+	}
+	if m.Text != "" {
+		detail = m.Text
+	}
+	if detail != "" {
+		out += " " + detail
+	}
+	return truncate(out)
+}
+
+func truncate(s string) string {
+	s = strings.Join(strings.Fields(s), " ") // 換行壓成空白
+	if utf8.RuneCountInString(s) <= previewMaxLen {
+		return s
+	}
+	r := []rune(s)
+	return string(r[:previewMaxLen]) + "…"
+}
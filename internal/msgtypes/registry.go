@@ -0,0 +1,285 @@
+// Package msgtypes 是 DM 訊息種類的登錄表：每種 type 有版本化的內容 schema，
+// 送出時做驗證 + 正規化（trim、去掉未知欄位），並依語系產生對話列表的預覽文字。
+package msgtypes
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// 欄位型別
+const (
+	KindString = "string"
+	KindNumber = "number"
+	KindBool   = "bool"
+	KindArray  = "array" // 元素為 object，欄位定義在 Items
+)
+
+type Field struct {
+	Name     string
+	Kind     string
+	Required bool
+	MaxLen   int     // string：最大字數（rune）；array：最多幾個元素
+	MinItems int     // array 專用
+	URL      bool    // string 專用：必須是 /uploads/... 或 https:// 開頭
+	Search   bool    // string 專用：納入訊息搜尋（卡片名稱、專輯名稱…）
+	Items    []Field // array 專用
+}
+
+type Schema struct {
+	Type    string
+	Version int
+	Fields  []Field
+
+	TextRequired bool // text 欄位必填（純文字訊息）
+	TextMaxLen   int  // 0 = 不允許 text
+	ServerOnly   bool // 只能由伺服器產生（例如 system），用戶端不能送
+}
+
+// schema 識別字，例如 "miniCard.v1"
+func (s Schema) ID() string { return fmt.Sprintf("%s.v%d", s.Type, s.Version) }
+
+var registry = map[string][]Schema{
+	"text": {{
+		Type: "text", Version: 1,
+		TextRequired: true, TextMaxLen: 4000,
+	}},
+	"image": {{
+		Type: "image", Version: 1,
+		TextMaxLen: 1000, // 圖片說明
+		Fields: []Field{
+			{Name: "url", Kind: KindString, Required: true, MaxLen: 2048, URL: true},
+			{Name: "width", Kind: KindNumber},
+			{Name: "height", Kind: KindNumber},
+		},
+	}},
+	"miniCard": {{
+		Type: "miniCard", Version: 1,
+		TextMaxLen: 1000,
+		Fields: []Field{
+			{Name: "cardId", Kind: KindString, Required: true, MaxLen: 128},
+			{Name: "name", Kind: KindString, Required: true, MaxLen: 200, Search: true},
+			{Name: "idol", Kind: KindString, MaxLen: 100, Search: true},
+			{Name: "group", Kind: KindString, MaxLen: 100, Search: true},
+			{Name: "imageUrl", Kind: KindString, MaxLen: 2048, URL: true},
+			{Name: "backImageUrl", Kind: KindString, MaxLen: 2048, URL: true},
+		},
+	}},
+	"album": {{
+		Type: "album", Version: 1,
+		TextMaxLen: 1000,
+		Fields: []Field{
+			{Name: "albumId", Kind: KindString, Required: true, MaxLen: 128},
+			{Name: "title", Kind: KindString, Required: true, MaxLen: 200, Search: true},
+			{Name: "artist", Kind: KindString, MaxLen: 200, Search: true},
+			{Name: "coverUrl", Kind: KindString, MaxLen: 2048, URL: true},
+			{Name: "cardCount", Kind: KindNumber},
+		},
+	}},
+	"tradeOffer": {{
+		Type: "tradeOffer", Version: 1,
+		TextMaxLen: 1000,
+		Fields: []Field{
+			{Name: "offer", Kind: KindArray, Required: true, MinItems: 1, MaxLen: 20, Items: tradeCardFields},
+			{Name: "want", Kind: KindArray, MaxLen: 20, Items: tradeCardFields},
+			{Name: "note", Kind: KindString, MaxLen: 500, Search: true},
+		},
+	}},
+	"sticker": {{
+		Type: "sticker", Version: 1,
+		Fields: []Field{
+			{Name: "packId", Kind: KindString, Required: true, MaxLen: 64},
+			{Name: "stickerId", Kind: KindString, Required: true, MaxLen: 64},
+			{Name: "url", Kind: KindString, MaxLen: 2048, URL: true},
+		},
+	}},
+	"system": {{
+		Type: "system", Version: 1,
+		ServerOnly: true,
+	}},
+}
+
+var tradeCardFields = []Field{
+	{Name: "cardId", Kind: KindString, Required: true, MaxLen: 128},
+	{Name: "name", Kind: KindString, Required: true, MaxLen: 200, Search: true},
+	{Name: "imageUrl", Kind: KindString, MaxLen: 2048, URL: true},
+}
+
+// 找出 type 對應的 schema；schemaID 為空時用最新版本
+func Lookup(msgType, schemaID string) (Schema, bool) {
+	versions := registry[msgType]
+	if len(versions) == 0 {
+		return Schema{}, false
+	}
+	if schemaID == "" {
+		return versions[len(versions)-1], true
+	}
+	for _, s := range versions {
+		if s.ID() == schemaID {
+			return s, true
+		}
+	}
+	return Schema{}, false
+}
+
+type FieldError struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+type ValidationError struct {
+	Fields []FieldError `json:"fields"`
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		parts = append(parts, f.Field+": "+f.Reason)
+	}
+	return "invalid message: " + strings.Join(parts, "; ")
+}
+
+func (e *ValidationError) add(field, reason string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Reason: reason})
+}
+
+// 用戶端送來的訊息內容，驗證 + 正規化後的結果
+type Normalized struct {
+	Type          string
+	ContentSchema string
+	Text          string
+	ContentJson   map[string]any
+}
+
+// 驗證並正規化：未知 type / schema、必填缺漏、型別錯誤、超長都會列在 *ValidationError
+func Normalize(msgType, schemaID, text string, content map[string]any) (Normalized, error) {
+	verr := &ValidationError{}
+	if msgType == "" {
+		msgType = "text"
+	}
+	schema, ok := Lookup(msgType, strings.TrimSpace(schemaID))
+	if !ok {
+		if _, known := registry[msgType]; known {
+			verr.add("contentSchema", "unknown schema version")
+		} else {
+			verr.add("type", "unknown message type")
+		}
+		return Normalized{}, verr
+	}
+	if schema.ServerOnly {
+		verr.add("type", "reserved for server")
+		return Normalized{}, verr
+	}
+
+	text = strings.TrimSpace(text)
+	switch {
+	case schema.TextRequired && text == "":
+		verr.add("text", "required")
+	case schema.TextMaxLen == 0 && text != "":
+		verr.add("text", "not allowed for this type")
+	case schema.TextMaxLen > 0 && utf8.RuneCountInString(text) > schema.TextMaxLen:
+		verr.add("text", fmt.Sprintf("longer than %d characters", schema.TextMaxLen))
+	}
+
+	var out map[string]any
+	if len(schema.Fields) > 0 {
+		out = normalizeObject("contentJson", schema.Fields, content, verr)
+	}
+
+	if len(verr.Fields) > 0 {
+		return Normalized{}, verr
+	}
+	return Normalized{
+		Type:          schema.Type,
+		ContentSchema: schema.ID(),
+		Text:          text,
+		ContentJson:   out,
+	}, nil
+}
+
+func normalizeObject(path string, fields []Field, in map[string]any, verr *ValidationError) map[string]any {
+	out := map[string]any{}
+	for _, f := range fields {
+		p := path + "." + f.Name
+		v, present := in[f.Name]
+		if !present || v == nil {
+			if f.Required {
+				verr.add(p, "required")
+			}
+			continue
+		}
+		switch f.Kind {
+		case KindString:
+			s, ok := v.(string)
+			if !ok {
+				verr.add(p, "must be a string")
+				continue
+			}
+			s = strings.TrimSpace(s)
+			if s == "" {
+				if f.Required {
+					verr.add(p, "required")
+				}
+				continue
+			}
+			if f.MaxLen > 0 && utf8.RuneCountInString(s) > f.MaxLen {
+				verr.add(p, fmt.Sprintf("longer than %d characters", f.MaxLen))
+				continue
+			}
+			if f.URL && !strings.HasPrefix(s, "/uploads/") && !strings.HasPrefix(s, "https://") {
+				verr.add(p, "must be an /uploads/ path or https URL")
+				continue
+			}
+			out[f.Name] = s
+		case KindNumber:
+			switch n := v.(type) {
+			case float64:
+				out[f.Name] = n
+			case string:
+				// 有些用戶端會把數字送成字串
+				x, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+				if err != nil {
+					verr.add(p, "must be a number")
+					continue
+				}
+				out[f.Name] = x
+			default:
+				verr.add(p, "must be a number")
+			}
+		case KindBool:
+			b, ok := v.(bool)
+			if !ok {
+				verr.add(p, "must be a boolean")
+				continue
+			}
+			out[f.Name] = b
+		case KindArray:
+			list, ok := v.([]any)
+			if !ok {
+				verr.add(p, "must be an array")
+				continue
+			}
+			if len(list) < f.MinItems {
+				verr.add(p, fmt.Sprintf("needs at least %d item(s)", f.MinItems))
+				continue
+			}
+			if f.MaxLen > 0 && len(list) > f.MaxLen {
+				verr.add(p, fmt.Sprintf("more than %d items", f.MaxLen))
+				continue
+			}
+			items := make([]any, 0, len(list))
+			for i, it := range list {
+				obj, ok := it.(map[string]any)
+				if !ok {
+					verr.add(fmt.Sprintf("%s[%d]", p, i), "must be an object")
+					continue
+				}
+				items = append(items, normalizeObject(fmt.Sprintf("%s[%d]", p, i), f.Items, obj, verr))
+			}
+			out[f.Name] = items
+		}
+	}
+	return out
+}
@@ -0,0 +1,65 @@
+package msgtypes
+
+import (
+	"strings"
+	"unicode"
+
+	"local.dev/socialdemo-backend/internal/models"
+)
+
+// 訊息可被搜尋的文字：Text + schema 裡標了 Search 的欄位（用換行分隔，避免跨欄位誤配）
+func SearchText(m models.Message) string {
+	parts := make([]string, 0, 4)
+	if m.Text != "" {
+		parts = append(parts, m.Text)
+	}
+	schema, ok := Lookup(m.Type, m.ContentSchema)
+	if !ok {
+		// 不認得的 schema 版本（例如已下架的舊版）：照這個 type 最新的版本找可搜尋欄位
+		schema, ok = Lookup(m.Type, "")
+	}
+	if ok {
+		parts = collectSearchable(schema.Fields, m.ContentJson, parts)
+	}
+	return strings.Join(parts, "\n")
+}
+
+func collectSearchable(fields []Field, obj map[string]any, out []string) []string {
+	for _, f := range fields {
+		switch f.Kind {
+		case KindString:
+			if v, ok := obj[f.Name].(string); ok && f.Search && v != "" {
+				out = append(out, v)
+			}
+		case KindArray:
+			list, _ := obj[f.Name].([]any)
+			for _, it := range list {
+				if o, ok := it.(map[string]any); ok {
+					out = collectSearchable(f.Items, o, out)
+				}
+			}
+		}
+	}
+	return out
+}
+
+// 搜尋用的正規化：全形英數轉半形、轉小寫。中日韓文字沒有空白斷詞，直接做子字串比對
+func Fold(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		switch {
+		case r == '　': // 全形空白
+			r = ' '
+		case r >= '！' && r <= '～': // 全形 ASCII
+			r = r - 0xfee0
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// 查詢字串拆成關鍵字（空白分隔，全部都要出現）
+func SearchTerms(q string) []string {
+	return strings.Fields(Fold(q))
+}
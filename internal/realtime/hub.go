@@ -0,0 +1,109 @@
+// Package realtime 是行程內的 pub/sub：每個使用者可以有多條連線（多裝置 / 多分頁），
+// 發佈時對該使用者的所有連線 fan-out。只存在記憶體，重啟即清空。
+package realtime
+
+import (
+	"sync"
+	"time"
+)
+
+// 事件種類
+const (
+	EventMessageNew    = "message.new"
+	EventMessageEdit   = "message.edit"
+	EventMessageDelete = "message.delete"
+	EventRead          = "read"
+	EventTyping        = "typing"
+
+	EventConversationUpdated = "conversation.updated" // 改名 / 成員 / 管理員異動
+)
+
+type Event struct {
+	Type           string `json:"type"`
+	ConversationID string `json:"conversationId,omitempty"`
+	UserID         string `json:"userId,omitempty"` // 觸發事件的人
+	Data           any    `json:"data,omitempty"`
+	At             string `json:"at"`
+	Silent         bool   `json:"silent,omitempty"` // 接收者已靜音這個對話：照常更新畫面，但不要跳通知 / 響鈴
+}
+
+// 一條連線的訂閱；C 有緩衝，滿了就丟事件（慢的連線不能卡住發佈端）
+type Subscriber struct {
+	UserID string
+	C      chan Event
+}
+
+type Hub struct {
+	mu   sync.RWMutex
+	subs map[string]map[*Subscriber]struct{} // userId -> 連線們
+
+	// 🔻 上線狀態（presence.go）
+	lastSeen map[string]time.Time            // userId -> 最後一條連線斷開的時間
+	typing   map[string]map[string]time.Time // convId -> userId -> 過期時間
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		subs:     map[string]map[*Subscriber]struct{}{},
+		lastSeen: map[string]time.Time{},
+		typing:   map[string]map[string]time.Time{},
+	}
+}
+
+func (h *Hub) Subscribe(uid string) *Subscriber {
+	sub := &Subscriber{UserID: uid, C: make(chan Event, 64)}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	set := h.subs[uid]
+	if set == nil {
+		set = map[*Subscriber]struct{}{}
+		h.subs[uid] = set
+	}
+	set[sub] = struct{}{}
+	return sub
+}
+
+func (h *Hub) Unsubscribe(sub *Subscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	set := h.subs[sub.UserID]
+	if set == nil {
+		return
+	}
+	delete(set, sub)
+	if len(set) == 0 {
+		// 最後一條連線斷開 → 記下最後上線時間
+		delete(h.subs, sub.UserID)
+		now := time.Now()
+		h.pruneLocked(now)
+		h.lastSeen[sub.UserID] = now
+	}
+}
+
+// 推給某位使用者的所有連線（非阻塞）
+func (h *Hub) Publish(uid string, ev Event) {
+	if ev.At == "" {
+		ev.At = time.Now().UTC().Format(time.RFC3339)
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for sub := range h.subs[uid] {
+		select {
+		case sub.C <- ev:
+		default:
+		}
+	}
+}
+
+func (h *Hub) PublishMany(uids []string, ev Event) {
+	for _, uid := range uids {
+		h.Publish(uid, ev)
+	}
+}
+
+// 目前這位使用者有幾條連線
+func (h *Hub) Connections(uid string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.subs[uid])
+}
@@ -0,0 +1,90 @@
+package realtime
+
+import (
+	"time"
+)
+
+// 上線狀態 / 輸入中狀態：全部只放記憶體，靠 TTL 自然過期
+
+const (
+	EventPresence = "presence"
+
+	TypingTTL   = 6 * time.Second     // 沒有續送 typing 就視為停止輸入
+	LastSeenTTL = 30 * 24 * time.Hour // 最後上線時間只保留 30 天
+)
+
+type PresenceInfo struct {
+	UserID   string `json:"userId"`
+	Online   bool   `json:"online"`
+	LastSeen string `json:"lastSeen,omitempty"`
+}
+
+// 查詢某位使用者的上線狀態（是否隱藏 lastSeen 由呼叫端依 Profile 決定）
+func (h *Hub) Presence(uid string) PresenceInfo {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	info := PresenceInfo{UserID: uid, Online: len(h.subs[uid]) > 0}
+	if info.Online {
+		info.LastSeen = time.Now().UTC().Format(time.RFC3339)
+		return info
+	}
+	if t, ok := h.lastSeen[uid]; ok && time.Since(t) < LastSeenTTL {
+		info.LastSeen = t.UTC().Format(time.RFC3339)
+	}
+	return info
+}
+
+// 設定 / 取消某人在對話中的「輸入中」
+func (h *Hub) SetTyping(convID, uid string, typing bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.pruneLocked(time.Now())
+	set := h.typing[convID]
+	if !typing {
+		if set != nil {
+			delete(set, uid)
+			if len(set) == 0 {
+				delete(h.typing, convID)
+			}
+		}
+		return
+	}
+	if set == nil {
+		set = map[string]time.Time{}
+		h.typing[convID] = set
+	}
+	set[uid] = time.Now().Add(TypingTTL)
+}
+
+// 對話中目前正在輸入的人
+func (h *Hub) TypingIn(convID string) []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	now := time.Now()
+	out := make([]string, 0)
+	for uid, exp := range h.typing[convID] {
+		if now.Before(exp) {
+			out = append(out, uid)
+		}
+	}
+	return out
+}
+
+// 清掉過期的 typing / lastSeen（呼叫端需持有寫鎖）
+func (h *Hub) pruneLocked(now time.Time) {
+	for convID, set := range h.typing {
+		for uid, exp := range set {
+			if !now.Before(exp) {
+				delete(set, uid)
+			}
+		}
+		if len(set) == 0 {
+			delete(h.typing, convID)
+		}
+	}
+	for uid, t := range h.lastSeen {
+		if now.Sub(t) >= LastSeenTTL {
+			delete(h.lastSeen, uid)
+		}
+	}
+}
@@ -0,0 +1,441 @@
+package store
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"local.dev/socialdemo-backend/internal/models"
+)
+
+// ===== 連結身分與帳號合併 =====
+// identities[識別碼] = 主帳號的身分鍵。識別碼可以是 email（小寫）、Firebase UID 或 dev 身分；
+// 登入時先換成主帳號，所以同一個人不論用哪種方式登入都是同一個帳號。
+// 合併帳號（MergeAccounts）會把舊帳號的所有資料改掛到主帳號，之後舊帳號的識別碼也指向主帳號。
+
+var (
+	ErrSameAccount    = errors.New("cannot merge an account into itself")
+	ErrAccountUnknown = errors.New("account not found")
+)
+
+// 連結最多追幾層（合併過再合併）
+const maxIdentityHops = 10
+
+func (s *Store) LoadIdentities(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.identities == nil {
+		s.identities = make(map[string]string)
+	}
+	_ = readJSONFile(path, &s.identities)
+}
+
+func (s *Store) SaveIdentities(path string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_ = writeJSONFile(path, s.identities)
+}
+
+// 識別碼 → 主帳號（沒有連結就是自己）
+func (s *Store) CanonicalAccount(id string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.canonicalLocked(id)
+}
+
+func (s *Store) canonicalLocked(id string) string {
+	for i := 0; i < maxIdentityHops; i++ {
+		next, ok := s.identities[id]
+		if !ok || next == id {
+			return id
+		}
+		id = next
+	}
+	return id
+}
+
+// 把識別碼連到帳號。識別碼自己已經有資料（profile）時不自動連結，要走 MergeAccounts；
+// 回傳是否有變動（需要存檔）
+func (s *Store) LinkIdentity(identifier, account string) bool {
+	if identifier == "" || account == "" || identifier == account {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	account = s.canonicalLocked(account)
+	if _, linked := s.identities[identifier]; linked || identifier == account {
+		return false
+	}
+	if _, hasData := s.profiles[identifier]; hasData {
+		return false
+	}
+	if s.identities == nil {
+		s.identities = make(map[string]string)
+	}
+	s.identities[identifier] = account
+	return true
+}
+
+// 連到 account 的登入識別碼（包含帳號本身），排序後回傳
+func (s *Store) ListIdentities(account string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	account = s.canonicalLocked(account)
+	out := []string{account}
+	for id := range s.identities {
+		if strings.HasPrefix(id, publicIDPrefix) {
+			continue // 合併掉的舊 PublicID，不是登入用的識別碼
+		}
+		if id != account && s.canonicalLocked(id) == account {
+			out = append(out, id)
+		}
+	}
+	sort.Strings(out[1:])
+	return out
+}
+
+// 合併結果（各類資料改掛了幾筆）
+type MergeResult struct {
+	From          string `json:"from"`
+	Into          string `json:"into"`
+	Posts         int    `json:"posts"`
+	Comments      int    `json:"comments"`
+	Likes         int    `json:"likes"`
+	Follows       int    `json:"follows"`
+	Boards        int    `json:"boards"`
+	Conversations int    `json:"conversations"`
+	Messages      int    `json:"messages"`
+	Exports       int    `json:"exports"`
+	// from 原本排定的刪除帳號申請被取消
+	DeletionCancelled bool `json:"deletionCancelled,omitempty"`
+}
+
+// 把 from 帳號的資料全部併到 into：貼文 / 留言作者、按讚、追蹤（雙向）與追蹤請求、標籤、
+// board 擁有者與管理員、對話成員與訊息寄件者、對話設定、封鎖、靜音、個人資料匯出、profile 空白欄位。
+// from 的刪除帳號申請直接取消（資料已經屬於 into，不能再依 from 的申請清掉）。
+// library_<uid>.json 與匯出 zip 是檔案，由呼叫端處理（zip 以匯出 ID 命名，不用搬）。
+func (s *Store) MergeAccounts(from, into string) (MergeResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	from, into = s.canonicalLocked(from), s.canonicalLocked(into)
+	res := MergeResult{From: from, Into: into}
+	if from == into {
+		return res, ErrSameAccount
+	}
+	if _, ok := s.profiles[from]; !ok {
+		return res, ErrAccountUnknown
+	}
+	if _, ok := s.profiles[into]; !ok {
+		return res, ErrAccountUnknown
+	}
+
+	rename := func(id string) string {
+		if id == from {
+			return into
+		}
+		return id
+	}
+	renameAll := func(ids []string) []string {
+		if ids == nil {
+			return nil
+		}
+		out := make([]string, 0, len(ids))
+		for _, id := range ids {
+			if id = rename(id); !containsString(out, id) {
+				out = append(out, id)
+			}
+		}
+		return out
+	}
+
+	// 貼文 / 留言
+	for i := range s.posts {
+		if s.posts[i].Author.ID == from {
+			s.posts[i].Author.ID = into
+			res.Posts++
+		}
+		for j := range s.posts[i].Comments {
+			if s.posts[i].Comments[j].Author.ID == from {
+				s.posts[i].Comments[j].Author.ID = into
+				res.Comments++
+			}
+		}
+	}
+
+	// 按讚（兩個帳號都按過同一篇只算一次）
+	for _, set := range s.postLikes {
+		if _, ok := set[from]; ok {
+			delete(set, from)
+			set[into] = struct{}{}
+			res.Likes++
+		}
+	}
+
+	// 標籤取聯集
+	for _, t := range s.tags[from] {
+		if !containsString(s.tags[into], t) {
+			s.tags[into] = append(s.tags[into], t)
+		}
+	}
+	delete(s.tags, from)
+
+	// 追蹤：from 追蹤的人改成 into 追蹤；追蹤 from 的人改成追蹤 into（不會追蹤自己）
+	for target := range s.friends[from] {
+		if target != into {
+			if s.friends[into] == nil {
+				s.friends[into] = make(map[string]struct{})
+			}
+			s.friends[into][target] = struct{}{}
+			res.Follows++
+		}
+	}
+	delete(s.friends, from)
+	for uid, set := range s.friends {
+		if _, ok := set[from]; ok {
+			delete(set, from)
+			if uid != into {
+				set[into] = struct{}{}
+				res.Follows++
+			}
+		}
+	}
+	s.rebuildFollowersLocked()
+
+	// 追蹤請求：收到的併到 into；送出的改成 into 送出
+	for requester, at := range s.followReq[from] {
+		if requester == into {
+			continue
+		}
+		if _, follows := s.friends[requester][into]; follows {
+			continue
+		}
+		if s.followReq[into] == nil {
+			s.followReq[into] = make(map[string]string)
+		}
+		if _, ok := s.followReq[into][requester]; !ok {
+			s.followReq[into][requester] = at
+		}
+	}
+	delete(s.followReq, from)
+	for target, set := range s.followReq {
+		if at, ok := set[from]; ok {
+			delete(set, from)
+			if _, follows := s.friends[into][target]; target != into && !follows {
+				set[into] = at
+			}
+		}
+	}
+
+	// Profile：保留 into 的，空白欄位用 from 的補；from 的 PublicID 之後也指向 into
+	if s.identities == nil {
+		s.identities = make(map[string]string)
+	}
+	s.mergeProfileLocked(from, into)
+
+	// Boards
+	for id, b := range s.boards {
+		changed := b.OwnerID == from || containsString(b.ModeratorIDs, from)
+		if !changed {
+			continue
+		}
+		b.OwnerID = rename(b.OwnerID)
+		b.ModeratorIDs = withoutString(renameAll(b.ModeratorIDs), b.OwnerID)
+		s.boards[id] = b
+		res.Boards++
+	}
+
+	// 對話
+	directKeys := map[string]struct{}{}
+	for _, c := range s.conversations {
+		if c.DirectKey != "" && !containsString(c.MemberIDs, from) {
+			directKeys[c.DirectKey] = struct{}{}
+		}
+	}
+	for id, c := range s.conversations {
+		_, wasFormer := c.FormerMembers[from]
+		if !containsString(c.MemberIDs, from) && !wasFormer {
+			continue
+		}
+		c.MemberIDs = renameAll(c.MemberIDs)
+		c.AdminIDs = renameAll(c.AdminIDs)
+		c.PendingFor = renameAll(c.PendingFor)
+		c.DeclinedBy = renameAll(c.DeclinedBy)
+		c.CreatedBy = rename(c.CreatedBy)
+		// copy-on-write：GetConversation 回傳的副本仍共用舊 map，不能原地修改
+		if cur, ok := c.ReadCursors[from]; ok {
+			next := make(map[string]models.ReadCursor, len(c.ReadCursors))
+			for k, v := range c.ReadCursors {
+				if k != from {
+					next[k] = v
+				}
+			}
+			if ex, ok := next[into]; !ok || ex.MessageAt < cur.MessageAt {
+				next[into] = cur
+			}
+			c.ReadCursors = next
+		}
+		if fm, ok := c.FormerMembers[from]; ok {
+			next := make(map[string]models.FormerMember, len(c.FormerMembers))
+			for k, v := range c.FormerMembers {
+				if k != from {
+					next[k] = v
+				}
+			}
+			if !containsString(c.MemberIDs, into) {
+				next[into] = fm
+			}
+			c.FormerMembers = next
+		}
+		if c.Kind == ConversationDirect {
+			// 同一組人已經有一對一對話：這個就不再當作唯一的那一個
+			c.DirectKey = DirectKey(c.MemberIDs)
+			if _, dup := directKeys[c.DirectKey]; dup {
+				c.DirectKey = ""
+			} else {
+				directKeys[c.DirectKey] = struct{}{}
+			}
+		}
+		s.conversations[id] = c
+		res.Conversations++
+	}
+	for id, m := range s.messages {
+		changed := false
+		if m.SenderID == from {
+			m.SenderID = into
+			changed = true
+		}
+		if containsString(m.HiddenFor, from) {
+			m.HiddenFor = renameAll(m.HiddenFor)
+			changed = true
+		}
+		if changed {
+			s.messages[id] = m
+			res.Messages++
+		}
+	}
+
+	// 對話設定：同一個對話兩邊都有設定時以 into 的為準
+	for convID, st := range s.convSettings[from] {
+		if s.convSettings[into] == nil {
+			s.convSettings[into] = make(map[string]models.ConversationSettings)
+		}
+		if _, ok := s.convSettings[into][convID]; !ok {
+			s.convSettings[into][convID] = st
+		}
+	}
+	delete(s.convSettings, from)
+
+	// 封鎖（不會封鎖自己）
+	for target, at := range s.blocks[from] {
+		if target == into {
+			continue
+		}
+		if s.blocks[into] == nil {
+			s.blocks[into] = make(map[string]string)
+		}
+		if _, ok := s.blocks[into][target]; !ok {
+			s.blocks[into][target] = at
+		}
+	}
+	delete(s.blocks, from)
+	for blocker, set := range s.blocks {
+		if at, ok := set[from]; ok {
+			delete(set, from)
+			if blocker != into {
+				set[into] = at
+			}
+		}
+	}
+
+	// 靜音：into 沒設定才沿用 from 的
+	if m, ok := s.mutes[from]; ok {
+		if _, has := s.mutes[into]; !has {
+			s.mutes[into] = m
+		}
+		delete(s.mutes, from)
+	}
+	for uid, m := range s.mutes {
+		for i, e := range m.Accounts {
+			if e.Value == from {
+				m.Accounts[i].Value = into
+			}
+		}
+		s.mutes[uid] = m
+	}
+
+	delete(s.suggestions, from)
+	delete(s.suggestions, into)
+
+	// 刪除帳號申請 / 個人資料匯出
+	if _, ok := s.deletions[from]; ok {
+		delete(s.deletions, from)
+		res.DeletionCancelled = true
+	}
+	for id, e := range s.exports {
+		if e.OwnerID == from {
+			e.OwnerID = into
+			s.exports[id] = e
+			res.Exports++
+		}
+	}
+
+	// 連結：原本指向 from 的識別碼改指向 into，from 本身也連到 into
+	for id, acc := range s.identities {
+		if acc == from {
+			s.identities[id] = into
+		}
+	}
+	s.identities[from] = into
+	return res, nil
+}
+
+// 呼叫端需持有鎖
+func (s *Store) mergeProfileLocked(from, into string) {
+	src, dst := s.profiles[from], s.profiles[into]
+	if dst.Name == "" {
+		dst.Name = src.Name
+	}
+	fill := func(d **string, v *string) {
+		if (*d == nil || **d == "") && v != nil {
+			*d = v
+		}
+	}
+	fill(&dst.Nickname, src.Nickname)
+	fill(&dst.AvatarURL, src.AvatarURL)
+	fill(&dst.Instagram, src.Instagram)
+	fill(&dst.Facebook, src.Facebook)
+	fill(&dst.LineId, src.LineId)
+	if dst.Birthday == "" {
+		dst.Birthday = src.Birthday
+	}
+	if dst.VerifiedBadge == "" {
+		dst.VerifiedBadge = src.VerifiedBadge
+	}
+
+	// handle：into 沒有就接手；有的話 from 的 handle 釋出並保留給 into
+	if src.Handle != "" {
+		delete(s.handles, src.Handle)
+		if dst.Handle == "" {
+			dst.Handle = src.Handle
+			s.handles[dst.Handle] = into
+		} else {
+			dst.HandleHistory = append(dst.HandleHistory, models.HandleChange{Handle: src.Handle, ReleasedAt: nowISO()})
+		}
+	}
+	dst.HandleHistory = append(dst.HandleHistory, src.HandleHistory...)
+	sort.SliceStable(dst.HandleHistory, func(i, j int) bool {
+		return parseISO(dst.HandleHistory[i].ReleasedAt).Before(parseISO(dst.HandleHistory[j].ReleasedAt))
+	})
+	if len(dst.HandleHistory) > maxHandleHistory {
+		dst.HandleHistory = dst.HandleHistory[len(dst.HandleHistory)-maxHandleHistory:]
+	}
+
+	if src.PublicID != "" {
+		// 舊的公開連結還是找得到人（publicIDs 由 profiles 推出，重啟後靠 identities）
+		s.publicIDs[src.PublicID] = into
+		s.identities[src.PublicID] = into
+	}
+	s.profiles[into] = dst
+	delete(s.profiles, from)
+}
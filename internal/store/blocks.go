@@ -0,0 +1,137 @@
+package store
+
+import (
+	"sort"
+
+	"local.dev/socialdemo-backend/internal/models"
+)
+
+// ===== 封鎖 =====
+// blocks[blocker][blocked] = 封鎖時間。封鎖是雙向生效的：彼此都看不到對方的貼文 / 留言，
+// 也不能互相留言、按讚、私訊、追蹤。
+
+func (s *Store) LoadBlocks(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.blocks == nil {
+		s.blocks = make(map[string]map[string]string)
+	}
+	_ = readJSONFile(path, &s.blocks)
+}
+
+func (s *Store) SaveBlocks(path string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_ = writeJSONFile(path, s.blocks)
+}
+
+// 封鎖 target，並移除兩人之間的追蹤關係與追蹤請求；回傳追蹤關係是否有變動（需要存 friends.json）
+func (s *Store) Block(uid, target string) (followsChanged bool) {
+	if uid == "" || target == "" || uid == target {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.blocks == nil {
+		s.blocks = make(map[string]map[string]string)
+	}
+	if s.blocks[uid] == nil {
+		s.blocks[uid] = make(map[string]string)
+	}
+	if _, ok := s.blocks[uid][target]; !ok {
+		s.blocks[uid][target] = nowISO()
+	}
+	s.removeFollowRequestLocked(uid, target)
+	s.removeFollowRequestLocked(target, uid)
+	a := s.unfollowLocked(uid, target)
+	b := s.unfollowLocked(target, uid)
+	return a || b
+}
+
+func (s *Store) Unblock(uid, target string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.blocks[uid], target)
+	if len(s.blocks[uid]) == 0 {
+		delete(s.blocks, uid)
+	}
+}
+
+// uid 是否封鎖了 target
+func (s *Store) HasBlocked(uid, target string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.blocks[uid][target]
+	return ok
+}
+
+// 兩人之間任一方封鎖了另一方
+func (s *Store) IsBlockedEither(a, b string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.blockedEitherLocked(a, b)
+}
+
+func (s *Store) blockedEitherLocked(a, b string) bool {
+	if _, ok := s.blocks[a][b]; ok {
+		return true
+	}
+	_, ok := s.blocks[b][a]
+	return ok
+}
+
+type BlockedUser struct {
+	UserID    string `json:"userId"`
+	BlockedAt string `json:"blockedAt"`
+}
+
+// uid 封鎖的人，新 → 舊
+func (s *Store) ListBlocked(uid string) []BlockedUser {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]BlockedUser, 0, len(s.blocks[uid]))
+	for id, at := range s.blocks[uid] {
+		out = append(out, BlockedUser{UserID: id, BlockedAt: at})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].BlockedAt > out[j].BlockedAt })
+	return out
+}
+
+// viewer 看不到的人：自己封鎖的 + 封鎖自己的（呼叫端需持有鎖）
+func (s *Store) hiddenUsersLocked(viewerUID string) map[string]struct{} {
+	out := map[string]struct{}{}
+	if viewerUID == "" {
+		return out
+	}
+	for id := range s.blocks[viewerUID] {
+		out[id] = struct{}{}
+	}
+	for blocker, set := range s.blocks {
+		if _, ok := set[viewerUID]; ok {
+			out[blocker] = struct{}{}
+		}
+	}
+	return out
+}
+
+// 拿掉被封鎖的人的留言（不改動原本的 Comments slice）
+func withoutHiddenComments(p models.Post, hidden map[string]struct{}) models.Post {
+	if len(hidden) == 0 || len(p.Comments) == 0 {
+		return p
+	}
+	kept := make([]models.Comment, 0, len(p.Comments))
+	for _, c := range p.Comments {
+		if _, ok := hidden[c.Author.ID]; !ok {
+			kept = append(kept, c)
+		}
+	}
+	p.Comments = kept
+	return p
+}
+
+// 單篇貼文回應用：依 viewer 拿掉封鎖關係另一方的留言
+func (s *Store) FilterBlockedComments(p models.Post, viewerUID string) models.Post {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return withoutHiddenComments(p, s.hiddenUsersLocked(viewerUID))
+}
@@ -0,0 +1,350 @@
+package store
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"local.dev/socialdemo-backend/internal/models"
+	"local.dev/socialdemo-backend/internal/msgtypes"
+)
+
+// ===== DM：已讀游標 / 未讀數 =====
+
+// m 是否排在游標之後（先比 createdAt，同一秒再比 ID；ID 內含 UnixNano）
+func messageAfterCursor(m models.Message, c models.ReadCursor) bool {
+	if c.MessageID == "" {
+		return true
+	}
+	mt, ct := parseISO(m.CreatedAt), parseISO(c.MessageAt)
+	if !mt.Equal(ct) {
+		return mt.After(ct)
+	}
+	return m.ID > c.MessageID
+}
+
+// 計算 uid 在某對話的未讀數：別人傳的、沒刪除、在自己游標之後（呼叫端需持有鎖）
+func (s *Store) unreadCountLocked(c models.Conversation, uid string) int {
+	cur := c.ReadCursors[uid]
+	n := 0
+	ids := s.msgIndex[c.ID]
+	// 索引由舊到新：從最新一則往回數，碰到游標就停
+	for i := len(ids) - 1; i >= 0; i-- {
+		m := s.messages[ids[i]]
+		if !messageAfterCursor(m, cur) {
+			break
+		}
+		if m.Deleted || m.SenderID == uid || containsString(m.HiddenFor, uid) {
+			continue
+		}
+		n++
+	}
+	return n
+}
+
+func (s *Store) UnreadCount(convID, uid string) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c, ok := s.conversations[convID]
+	if !ok {
+		return 0
+	}
+	return s.unreadCountLocked(c, uid)
+}
+
+// 把 uid 的已讀游標推進到 messageID；messageID 為空 = 讀到最新一則。
+// 游標只會往前推，不會倒退。回傳更新後的游標；對話不存在或訊息不屬於此對話回傳 false。
+func (s *Store) MarkRead(convID, uid, messageID string) (models.ReadCursor, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.conversations[convID]
+	if !ok {
+		return models.ReadCursor{}, false
+	}
+
+	var target models.Message
+	if messageID != "" {
+		m, ok := s.messages[messageID]
+		if !ok || m.ConversationID != convID {
+			return models.ReadCursor{}, false
+		}
+		target = m
+	} else {
+		target = s.latestMessageLocked(convID)
+	}
+
+	cur := c.ReadCursors[uid]
+	if target.ID == "" || !messageAfterCursor(target, cur) {
+		return cur, true
+	}
+
+	cur = models.ReadCursor{
+		MessageID: target.ID,
+		MessageAt: target.CreatedAt,
+		ReadAt:    time.Now().UTC().Format(time.RFC3339),
+	}
+	// copy-on-write：GetConversation 回傳的副本仍共用舊 map，不能原地修改
+	next := make(map[string]models.ReadCursor, len(c.ReadCursors)+1)
+	for k, v := range c.ReadCursors {
+		next[k] = v
+	}
+	next[uid] = cur
+	c.ReadCursors = next
+	s.conversations[convID] = c
+	return cur, true
+}
+
+// 依成員游標算出「誰已讀到這則」（不含發送者本人）
+func SeenBy(c models.Conversation, m models.Message) []string {
+	out := make([]string, 0)
+	for _, id := range c.MemberIDs {
+		if id == m.SenderID {
+			continue
+		}
+		cur, ok := c.ReadCursors[id]
+		if !ok || cur.MessageID == "" {
+			continue
+		}
+		if !messageAfterCursor(m, cur) {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// 跟 uid 至少共用一個對話的所有人（上線狀態要通知的對象）；任一方封鎖對方的不算
+func (s *Store) ConversationPartners(uid string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	set := map[string]struct{}{}
+	for _, c := range s.conversations {
+		if !containsString(c.MemberIDs, uid) {
+			continue
+		}
+		for _, id := range c.MemberIDs {
+			if id != uid && !s.blockedEitherLocked(uid, id) {
+				set[id] = struct{}{}
+			}
+		}
+	}
+	out := make([]string, 0, len(set))
+	for id := range set {
+		out = append(out, id)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// ===== DM：訊息請求 =====
+// 接受 / 拒絕都在鎖內對最新的對話操作，不會蓋掉同時進來的加人、已讀等異動
+
+// 接受：把 uid 從 PendingFor / DeclinedBy 拿掉；changed=false 代表本來就不是請求（或對話不存在）
+func (s *Store) AcceptConversationRequest(convID, uid string) (c models.Conversation, changed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.conversations[convID]
+	if !ok || (!containsString(c.PendingFor, uid) && !containsString(c.DeclinedBy, uid)) {
+		return c, false
+	}
+	c.PendingFor = withoutString(c.PendingFor, uid)
+	c.DeclinedBy = withoutString(c.DeclinedBy, uid)
+	s.conversations[convID] = c
+	return c, true
+}
+
+// 拒絕：PendingFor → DeclinedBy；uid 不在 PendingFor 時回傳 false
+func (s *Store) DeclineConversationRequest(convID, uid string) (models.Conversation, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.conversations[convID]
+	if !ok || !containsString(c.PendingFor, uid) {
+		return c, false
+	}
+	c.PendingFor = withoutString(c.PendingFor, uid)
+	c.DeclinedBy = append(withoutString(c.DeclinedBy, uid), uid)
+	s.conversations[convID] = c
+	return c, true
+}
+
+// ===== DM：編輯 / 收回 / 只對自己刪除 =====
+
+var (
+	ErrMessageNotFound = errors.New("message not found")
+	ErrNotSender       = errors.New("only the sender can do this")
+	ErrEditWindow      = errors.New("edit window has passed")
+	ErrNotEditable     = errors.New("message cannot be edited")
+)
+
+// 編輯訊息文字：只有發送者、只能在 window 內、已收回的不能改；舊內容留在 EditHistory
+func (s *Store) EditMessage(convID, msgID, uid, text string, window time.Duration) (models.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.messages[msgID]
+	if !ok || m.ConversationID != convID || containsString(m.HiddenFor, uid) {
+		return models.Message{}, ErrMessageNotFound
+	}
+	if m.SenderID != uid {
+		return models.Message{}, ErrNotSender
+	}
+	if m.Deleted || m.Type != "text" {
+		return models.Message{}, ErrNotEditable
+	}
+	now := time.Now().UTC()
+	if window > 0 && now.Sub(parseISO(m.CreatedAt)) > window {
+		return models.Message{}, ErrEditWindow
+	}
+	if m.Text == text {
+		return m, nil
+	}
+
+	nowStr := now.Format(time.RFC3339)
+	history := append([]models.MessageEdit(nil), m.EditHistory...)
+	m.EditHistory = append(history, models.MessageEdit{Text: m.Text, EditedAt: nowStr})
+	m.Text = text
+	m.EditedAt = nowStr
+	s.messages[m.ID] = m
+	s.refreshLastMessageLocked(convID)
+	return m, nil
+}
+
+// 收回（對所有人刪除）：只留 tombstone，內容與編輯紀錄一併清掉
+func (s *Store) UnsendMessage(convID, msgID, uid string) (models.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.messages[msgID]
+	if !ok || m.ConversationID != convID || containsString(m.HiddenFor, uid) {
+		return models.Message{}, ErrMessageNotFound
+	}
+	if m.SenderID != uid {
+		return models.Message{}, ErrNotSender
+	}
+	if m.Deleted {
+		return m, nil
+	}
+	m.Deleted = true
+	m.DeletedAt = time.Now().UTC().Format(time.RFC3339)
+	m.Text = ""
+	m.ContentSchema = ""
+	m.ContentJson = nil
+	m.EditHistory = nil
+	s.messages[m.ID] = m
+	s.refreshLastMessageLocked(convID)
+	return m, nil
+}
+
+// 只對自己刪除：其他成員照常看得到
+func (s *Store) HideMessage(convID, msgID, uid string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.messages[msgID]
+	if !ok || m.ConversationID != convID {
+		return ErrMessageNotFound
+	}
+	if containsString(m.HiddenFor, uid) {
+		return nil
+	}
+	m.HiddenFor = append(append([]string(nil), m.HiddenFor...), uid)
+	s.messages[m.ID] = m
+	return nil
+}
+
+// 重新計算對話的 lastMessageAt / preview：取最新一則「沒被收回」的訊息（呼叫端需持有寫鎖）
+func (s *Store) refreshLastMessageLocked(convID string) {
+	c, ok := s.conversations[convID]
+	if !ok {
+		return
+	}
+	last := s.latestMessageLocked(convID)
+	c.LastMessageAt = last.CreatedAt
+	c.LastMessageID = last.ID
+	c.LastMessagePreview = ""
+	if last.ID != "" {
+		c.LastMessagePreview = msgtypes.Preview(last, DefaultLocale)
+	}
+	s.conversations[convID] = c
+}
+
+// ===== DM：一對一對話唯一化 =====
+
+const (
+	ConversationDirect = "direct"
+	ConversationGroup  = "group"
+)
+
+// 存在 conversations.json 裡的預覽用這個語系；回應時再依 viewer 語系重新產生
+const DefaultLocale = "en"
+
+// 一對一對話的唯一鍵：成員 ID 排序後串起來（與建立順序無關）
+func DirectKey(memberIDs []string) string {
+	ids := append([]string(nil), memberIDs...)
+	sort.Strings(ids)
+	return strings.Join(ids, "|")
+}
+
+// 一對一：同一組成員已有對話就直接回傳既有的（created=false），否則建立新的。
+// 查找與建立在同一把鎖內完成，避免同時點兩次「傳訊息」產生兩個對話。
+func (s *Store) GetOrCreateDirectConversation(c models.Conversation) (models.Conversation, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c.Kind = ConversationDirect
+	c.DirectKey = DirectKey(c.MemberIDs)
+	for _, ex := range s.conversations {
+		if ex.Kind == ConversationDirect && ex.DirectKey == c.DirectKey {
+			return ex, false
+		}
+	}
+	if c.ID == "" {
+		c.ID = newID("c")
+	}
+	s.conversations[c.ID] = c
+	return c, true
+}
+
+// 舊資料沒有 kind：兩人以下視為一對一，同一組人若有多個舊對話，只有最早的拿到 directKey
+func (s *Store) backfillConversationKinds() {
+	ids := make([]string, 0, len(s.conversations))
+	for id := range s.conversations {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return s.conversations[ids[i]].CreatedAt < s.conversations[ids[j]].CreatedAt
+	})
+
+	taken := map[string]struct{}{}
+	for _, c := range s.conversations {
+		if c.DirectKey != "" {
+			taken[c.DirectKey] = struct{}{}
+		}
+	}
+	for _, id := range ids {
+		c := s.conversations[id]
+		if c.Kind != "" {
+			continue
+		}
+		if len(c.MemberIDs) > 2 {
+			c.Kind = ConversationGroup
+		} else {
+			c.Kind = ConversationDirect
+			key := DirectKey(c.MemberIDs)
+			if _, dup := taken[key]; !dup {
+				c.DirectKey = key
+				taken[key] = struct{}{}
+			}
+		}
+		s.conversations[id] = c
+	}
+}
+
+func (s *Store) GetMessage(id string) (models.Message, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	m, ok := s.messages[id]
+	return m, ok
+}
@@ -0,0 +1,126 @@
+package store
+
+import (
+	"errors"
+
+	"local.dev/socialdemo-backend/internal/models"
+)
+
+// ===== DM：群組管理 =====
+// 改名 / 加人 / 踢人 / 管理員異動都在鎖內對最新的對話操作，
+// 同時進來的另一個異動（或已讀游標、lastMessage）不會被整份覆蓋掉。
+
+var ErrGroupNeedsAdmin = errors.New("group needs at least one admin")
+
+// 改名；名稱沒變時 changed=false
+func (s *Store) RenameConversation(convID, name string) (c models.Conversation, changed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.conversations[convID]
+	if !ok || c.Name == name {
+		return c, false
+	}
+	c.Name = name
+	s.conversations[convID] = c
+	return c, true
+}
+
+// 加入成員：已經在群組裡的略過；pending 是需要先接受請求的人（只對真的新加入的生效）。
+// 回傳實際加入的人
+func (s *Store) AddConversationMembers(convID string, ids, pending []string) (models.Conversation, []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.conversations[convID]
+	added := make([]string, 0)
+	if !ok {
+		return c, added
+	}
+	members := append([]string(nil), c.MemberIDs...)
+	former := make(map[string]models.FormerMember, len(c.FormerMembers))
+	for k, v := range c.FormerMembers {
+		former[k] = v
+	}
+	for _, m := range ids {
+		if m == "" || containsString(members, m) {
+			continue
+		}
+		members = append(members, m)
+		delete(former, m) // 重新加入就恢復完整權限
+		added = append(added, m)
+	}
+	if len(added) == 0 {
+		return c, added
+	}
+	waiting := append([]string(nil), c.PendingFor...)
+	for _, m := range added {
+		if containsString(pending, m) {
+			waiting = append(waiting, m)
+		}
+	}
+	c.MemberIDs = members
+	c.FormerMembers = former
+	c.PendingFor = waiting
+	s.conversations[convID] = c
+	return c, added
+}
+
+// 移出成員（踢人 / 退出）：一併拿掉管理員、請求狀態，記下離開時間點；
+// 最後一位管理員離開時由最早加入的成員接手。target 不是成員時回傳 false
+func (s *Store) RemoveConversationMember(convID, target string, left models.FormerMember) (models.Conversation, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.conversations[convID]
+	if !ok || !containsString(c.MemberIDs, target) {
+		return c, false
+	}
+	hadAdmins := len(c.AdminIDs) > 0
+	c.MemberIDs = withoutString(c.MemberIDs, target)
+	c.AdminIDs = withoutString(c.AdminIDs, target)
+	c.PendingFor = withoutString(c.PendingFor, target)
+	c.DeclinedBy = withoutString(c.DeclinedBy, target)
+	if hadAdmins && len(c.AdminIDs) == 0 && len(c.MemberIDs) > 0 {
+		c.AdminIDs = []string{c.MemberIDs[0]}
+	}
+	former := make(map[string]models.FormerMember, len(c.FormerMembers)+1)
+	for k, v := range c.FormerMembers {
+		former[k] = v
+	}
+	former[target] = left
+	c.FormerMembers = former
+	s.conversations[convID] = c
+	return c, true
+}
+
+// 指派 / 撤銷管理員。舊資料沒有 adminIds 時，第一次指派會把操作的人（actor）也列為管理員。
+// 沒有變動時 changed=false；撤銷後沒有管理員回傳 ErrGroupNeedsAdmin
+func (s *Store) SetConversationAdmin(convID, actor, target string, add bool) (c models.Conversation, changed bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.conversations[convID]
+	if !ok || !containsString(c.MemberIDs, target) {
+		return c, false, nil
+	}
+	admins := append([]string(nil), c.AdminIDs...)
+	if len(admins) == 0 {
+		admins = []string{actor}
+	}
+	if add {
+		if containsString(admins, target) && len(c.AdminIDs) > 0 {
+			return c, false, nil
+		}
+		if !containsString(admins, target) {
+			admins = append(admins, target)
+		}
+	} else {
+		if !containsString(admins, target) {
+			return c, false, nil
+		}
+		admins = withoutString(admins, target)
+		if len(admins) == 0 {
+			return c, false, ErrGroupNeedsAdmin
+		}
+	}
+	c.AdminIDs = admins
+	s.conversations[convID] = c
+	return c, true, nil
+}
@@ -0,0 +1,206 @@
+package store
+
+import (
+	"sort"
+
+	"local.dev/socialdemo-backend/internal/models"
+)
+
+// ===== DM：每個對話的訊息索引 + 分頁 =====
+//
+// msgIndex[convId] 依 (createdAt, ID) 由舊到新排好，分頁、未讀數、最新一則都不必再掃整個 messages。
+
+const (
+	DefaultMessagePage = 50
+	MaxMessagePage     = 200
+)
+
+// 游標：MessageID 有值時比 (createdAt, ID)；只有 MessageAt 時是純時間游標（同一秒不分先後）
+func compareMessage(m models.Message, c models.ReadCursor) int {
+	mt, ct := parseISO(m.CreatedAt), parseISO(c.MessageAt)
+	switch {
+	case mt.Before(ct):
+		return -1
+	case mt.After(ct):
+		return 1
+	case c.MessageID == "" || m.ID == c.MessageID:
+		return 0
+	case m.ID < c.MessageID:
+		return -1
+	default:
+		return 1
+	}
+}
+
+func messageLess(a, b models.Message) bool {
+	return compareMessage(a, models.ReadCursor{MessageID: b.ID, MessageAt: b.CreatedAt}) < 0
+}
+
+// 新訊息加進索引（呼叫端需持有寫鎖；幾乎都是最新一則，直接 append）
+func (s *Store) indexMessageLocked(m models.Message) {
+	if s.msgIndex == nil {
+		s.msgIndex = make(map[string][]string)
+	}
+	ids := s.msgIndex[m.ConversationID]
+	i := sort.Search(len(ids), func(i int) bool { return messageLess(m, s.messages[ids[i]]) })
+	ids = append(ids, "")
+	copy(ids[i+1:], ids[i:])
+	ids[i] = m.ID
+	s.msgIndex[m.ConversationID] = ids
+}
+
+// 載入 messages.json 後重建整份索引
+func (s *Store) rebuildMessageIndex() {
+	idx := make(map[string][]string)
+	for id, m := range s.messages {
+		idx[m.ConversationID] = append(idx[m.ConversationID], id)
+	}
+	for _, ids := range idx {
+		sort.Slice(ids, func(i, j int) bool { return messageLess(s.messages[ids[i]], s.messages[ids[j]]) })
+	}
+	s.msgIndex = idx
+}
+
+// 最新一則沒被收回的訊息；沒有時回傳零值（呼叫端需持有鎖）
+func (s *Store) latestMessageLocked(convID string) models.Message {
+	ids := s.msgIndex[convID]
+	for i := len(ids) - 1; i >= 0; i-- {
+		if m := s.messages[ids[i]]; !m.Deleted {
+			return m
+		}
+	}
+	return models.Message{}
+}
+
+// uid 看得到的最新一則訊息（沒被收回、也沒被 uid 自己隱藏）；沒有時回傳零值
+func (s *Store) LatestMessageFor(convID, uid string) models.Message {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ids := s.msgIndex[convID]
+	for i := len(ids) - 1; i >= 0; i-- {
+		if m := s.messages[ids[i]]; !m.Deleted && !containsString(m.HiddenFor, uid) {
+			return m
+		}
+	}
+	return models.Message{}
+}
+
+// uid 在對話裡送出過幾則訊息（不含系統訊息，收回的也算）
+func (s *Store) CountMessagesFrom(convID, uid string) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	n := 0
+	for _, id := range s.msgIndex[convID] {
+		if m := s.messages[id]; m.SenderID == uid && m.Type != "system" {
+			n++
+		}
+	}
+	return n
+}
+
+// 分頁條件（游標本身不含在結果內）
+type MessageQuery struct {
+	Before models.ReadCursor // 往前翻：比這個早的
+	After  models.ReadCursor // 往後追：比這個晚的（輪詢新訊息用）
+	Until  models.ReadCursor // 上限（含）：已離開的成員只看得到離開當下為止
+	Limit  int
+}
+
+type MessagePage struct {
+	Messages []models.Message `json:"messages"` // 一律由舊到新
+	HasMore  bool             `json:"hasMore"`  // 沒帶 after：還有更早的；帶 after：還有更新的
+}
+
+// 列出 viewer 看得到的訊息：已收回的以 tombstone 呈現（內容清空），只對自己刪除的略過。
+// 沒帶 After 時回傳「最新 N 則」（Before 之前）；帶 After 時回傳游標之後最早的 N 則
+func (s *Store) ListMessages(convID, viewerUID string, q MessageQuery) MessagePage {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if q.Limit <= 0 {
+		q.Limit = DefaultMessagePage
+	}
+	if q.Limit > MaxMessagePage {
+		q.Limit = MaxMessagePage
+	}
+
+	ids := s.msgIndex[convID]
+	lo, hi := 0, len(ids)
+	if q.After.MessageAt != "" {
+		lo = sort.Search(len(ids), func(i int) bool { return compareMessage(s.messages[ids[i]], q.After) > 0 })
+	}
+	if q.Before.MessageAt != "" {
+		hi = sort.Search(len(ids), func(i int) bool { return compareMessage(s.messages[ids[i]], q.Before) >= 0 })
+	}
+	if q.Until.MessageAt != "" {
+		if n := sort.Search(len(ids), func(i int) bool { return compareMessage(s.messages[ids[i]], q.Until) > 0 }); n < hi {
+			hi = n
+		}
+	}
+
+	visible := func(i int) (models.Message, bool) {
+		m := s.messages[ids[i]]
+		if containsString(m.HiddenFor, viewerUID) {
+			return m, false
+		}
+		m.HiddenFor = nil
+		if m.Deleted {
+			// tombstone：舊資料可能還留著內容，一律清空
+			m.Text, m.ContentSchema, m.ContentJson, m.EditHistory = "", "", nil, nil
+		}
+		return m, true
+	}
+
+	page := MessagePage{Messages: make([]models.Message, 0)}
+	if q.After.MessageAt != "" {
+		for i := lo; i < hi; i++ {
+			m, ok := visible(i)
+			if !ok {
+				continue
+			}
+			if len(page.Messages) == q.Limit {
+				page.HasMore = true
+				break
+			}
+			page.Messages = append(page.Messages, m)
+		}
+		return page
+	}
+
+	for i := hi - 1; i >= lo; i-- {
+		m, ok := visible(i)
+		if !ok {
+			continue
+		}
+		if len(page.Messages) == q.Limit {
+			page.HasMore = true
+			break
+		}
+		page.Messages = append(page.Messages, m)
+	}
+	// 收集時是由新到舊，翻回由舊到新
+	for i, j := 0, len(page.Messages)-1; i < j; i, j = i+1, j-1 {
+		page.Messages[i], page.Messages[j] = page.Messages[j], page.Messages[i]
+	}
+	return page
+}
+
+// 游標參數：訊息 ID 或 RFC3339 時間；解析不了回傳 false
+func (s *Store) MessageCursor(convID, v string) (models.ReadCursor, bool) {
+	if v == "" {
+		return models.ReadCursor{}, true
+	}
+	s.mu.RLock()
+	m, ok := s.messages[v]
+	s.mu.RUnlock()
+	if ok {
+		if m.ConversationID != convID {
+			return models.ReadCursor{}, false
+		}
+		return models.ReadCursor{MessageID: m.ID, MessageAt: m.CreatedAt}, true
+	}
+	if !parseISO(v).IsZero() {
+		return models.ReadCursor{MessageAt: v}, true
+	}
+	return models.ReadCursor{}, false
+}
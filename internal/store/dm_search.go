@@ -0,0 +1,139 @@
+package store
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"local.dev/socialdemo-backend/internal/models"
+	"local.dev/socialdemo-backend/internal/msgtypes"
+)
+
+// ===== DM：訊息搜尋 =====
+
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+
+	searchContextSize = 2  // 命中訊息前後各附幾則
+	snippetRadius     = 30 // 摘要在命中位置前後各取幾個字
+)
+
+// 搜尋範圍：一個對話；Until 有值時只搜到這則（已離開的成員）
+type SearchScope struct {
+	ConversationID string
+	Until          models.ReadCursor
+}
+
+type MessageHit struct {
+	Message models.Message   `json:"message"`
+	Snippet string           `json:"snippet"` // 命中位置附近的文字
+	Before  []models.Message `json:"before"`  // 前文（由舊到新）
+	After   []models.Message `json:"after"`   // 後文（由舊到新）
+}
+
+// 在多個對話裡找同時包含所有關鍵字的訊息（Text + 卡片名稱 / 專輯名稱等欄位），由新到舊
+func (s *Store) SearchMessages(scopes []SearchScope, viewerUID, query string, limit int) ([]MessageHit, bool) {
+	terms := msgtypes.SearchTerms(query)
+	if len(terms) == 0 {
+		return []MessageHit{}, false
+	}
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+	if limit > MaxSearchLimit {
+		limit = MaxSearchLimit
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	hits := make([]MessageHit, 0)
+	for _, sc := range scopes {
+		ids := s.msgIndex[sc.ConversationID]
+		hi := len(ids)
+		if sc.Until.MessageAt != "" {
+			hi = sort.Search(len(ids), func(i int) bool { return compareMessage(s.messages[ids[i]], sc.Until) > 0 })
+		}
+		// 每個對話最多取 limit+1 筆，合併後再截斷
+		found := 0
+		for i := hi - 1; i >= 0 && found <= limit; i-- {
+			m := s.messages[ids[i]]
+			if m.Deleted || containsString(m.HiddenFor, viewerUID) {
+				continue
+			}
+			text := msgtypes.SearchText(m)
+			folded := msgtypes.Fold(text)
+			if !containsAll(folded, terms) {
+				continue
+			}
+			m.HiddenFor = nil
+			hits = append(hits, MessageHit{
+				Message: m,
+				Snippet: snippet(text, folded, terms[0]),
+				Before:  s.searchContextLocked(ids[:i], viewerUID, true),
+				After:   s.searchContextLocked(ids[i+1:hi], viewerUID, false),
+			})
+			found++
+		}
+	}
+
+	sort.Slice(hits, func(i, j int) bool { return messageLess(hits[j].Message, hits[i].Message) })
+	more := len(hits) > limit
+	if more {
+		hits = hits[:limit]
+	}
+	return hits, more
+}
+
+func containsAll(s string, terms []string) bool {
+	for _, t := range terms {
+		if !strings.Contains(s, t) {
+			return false
+		}
+	}
+	return true
+}
+
+// 命中訊息旁邊的訊息（跳過收回 / 只對自己刪除的）；backward=true 取 ids 尾端往前
+func (s *Store) searchContextLocked(ids []string, viewerUID string, backward bool) []models.Message {
+	out := make([]models.Message, 0, searchContextSize)
+	for k := 0; k < len(ids) && len(out) < searchContextSize; k++ {
+		i := k
+		if backward {
+			i = len(ids) - 1 - k
+		}
+		m := s.messages[ids[i]]
+		if m.Deleted || containsString(m.HiddenFor, viewerUID) {
+			continue
+		}
+		m.HiddenFor = nil
+		out = append(out, m)
+	}
+	if backward {
+		for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+			out[i], out[j] = out[j], out[i]
+		}
+	}
+	return out
+}
+
+// 以第一個關鍵字的位置為中心截一段文字；Fold 不改變字數，可以用 rune 位置對回原文
+func snippet(text, folded, term string) string {
+	at := strings.Index(folded, term)
+	if at < 0 {
+		at = 0
+	}
+	orig := []rune(text)
+	start := utf8.RuneCountInString(folded[:at])
+	end := start + utf8.RuneCountInString(term) + snippetRadius
+	start -= snippetRadius
+	prefix, suffix := "…", "…"
+	if start <= 0 {
+		start, prefix = 0, ""
+	}
+	if end >= len(orig) {
+		end, suffix = len(orig), ""
+	}
+	return prefix + strings.Join(strings.Fields(string(orig[start:end])), " ") + suffix
+}
@@ -0,0 +1,83 @@
+package store
+
+import (
+	"time"
+
+	"local.dev/socialdemo-backend/internal/models"
+)
+
+// ===== DM：每位成員自己的對話設定（靜音 / 封存 / 置頂 / 暱稱） =====
+// 跟 conversations.json 分開存，改自己的設定不會動到別人看到的對話
+
+// 永久靜音
+const ForeverMuted = "9999-12-31T23:59:59Z"
+
+func (s *Store) LoadConversationSettings(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.convSettings == nil {
+		s.convSettings = make(map[string]map[string]models.ConversationSettings)
+	}
+	_ = readJSONFile(path, &s.convSettings)
+}
+
+func (s *Store) SaveConversationSettings(path string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_ = writeJSONFile(path, s.convSettings)
+}
+
+// 補上計算欄位（muted / pinned）
+func withComputed(st models.ConversationSettings, now time.Time) models.ConversationSettings {
+	st.Muted = st.MutedUntil != "" && parseISO(st.MutedUntil).After(now)
+	if !st.Muted {
+		st.MutedUntil = ""
+	}
+	st.Pinned = st.PinnedAt != ""
+	return st
+}
+
+func (s *Store) conversationSettingsLocked(uid, convID string) models.ConversationSettings {
+	return withComputed(s.convSettings[uid][convID], time.Now().UTC())
+}
+
+func (s *Store) GetConversationSettings(uid, convID string) models.ConversationSettings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.conversationSettingsLocked(uid, convID)
+}
+
+func (s *Store) SetConversationSettings(uid, convID string, st models.ConversationSettings) models.ConversationSettings {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.convSettings == nil {
+		s.convSettings = make(map[string]map[string]models.ConversationSettings)
+	}
+	if s.convSettings[uid] == nil {
+		s.convSettings[uid] = make(map[string]models.ConversationSettings)
+	}
+	st.Muted, st.Pinned = false, false // 計算欄位不存檔
+	st.UpdatedAt = nowISO()
+	s.convSettings[uid][convID] = st
+	return withComputed(st, time.Now().UTC())
+}
+
+// uid 目前置頂的對話數
+func (s *Store) PinnedConversationCount(uid string) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	n := 0
+	for convID, st := range s.convSettings[uid] {
+		if _, ok := s.conversations[convID]; ok && st.PinnedAt != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// 推送通知前檢查：uid 是否把這個對話靜音中
+func (s *Store) IsConversationMuted(uid, convID string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.conversationSettingsLocked(uid, convID).Muted
+}
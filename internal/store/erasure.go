@@ -0,0 +1,431 @@
+package store
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"local.dev/socialdemo-backend/internal/models"
+)
+
+// ===== 刪除帳號 =====
+// 兩段式：先申請拿確認碼，帶確認碼再呼叫一次才排程；緩衝期過後由背景工作呼叫 EraseAccount。
+// 清除後其他資料裡不能再留下這個人的身分鍵，也不能留下他的 PublicID（系統訊息內容等）。
+
+var (
+	ErrDeletionNotRequested = errors.New("no pending deletion request")
+	ErrDeletionToken        = errors.New("invalid or expired confirmation token")
+)
+
+// 確認碼有效時間
+const deletionConfirmWindow = 15 * time.Minute
+
+func (s *Store) LoadDeletions(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.deletions == nil {
+		s.deletions = make(map[string]models.AccountDeletion)
+	}
+	_ = readJSONFile(path, &s.deletions)
+}
+
+func (s *Store) SaveDeletions(path string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_ = writeJSONFile(path, s.deletions)
+}
+
+func newConfirmToken() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// 申請刪除：發一組新的確認碼（已經確認排程的申請原樣回傳）
+func (s *Store) RequestDeletion(uid string, now time.Time) models.AccountDeletion {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if d, ok := s.deletions[uid]; ok && d.ConfirmedAt != "" {
+		return d
+	}
+	d := models.AccountDeletion{
+		RequestedAt:    now.Format(time.RFC3339),
+		ConfirmToken:   newConfirmToken(),
+		ConfirmExpires: now.Add(deletionConfirmWindow).Format(time.RFC3339),
+	}
+	s.deletions[uid] = d
+	return d
+}
+
+// 帶確認碼確認，排程在 now+grace 清除
+func (s *Store) ConfirmDeletion(uid, token string, now time.Time, grace time.Duration) (models.AccountDeletion, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, ok := s.deletions[uid]
+	if !ok {
+		return d, ErrDeletionNotRequested
+	}
+	if d.ConfirmedAt != "" {
+		return d, nil
+	}
+	if token == "" || token != d.ConfirmToken || now.After(parseISO(d.ConfirmExpires)) {
+		return d, ErrDeletionToken
+	}
+	d.ConfirmToken, d.ConfirmExpires = "", ""
+	d.ConfirmedAt = now.Format(time.RFC3339)
+	d.PurgeAt = now.Add(grace).Format(time.RFC3339)
+	s.deletions[uid] = d
+	return d, nil
+}
+
+func (s *Store) GetDeletion(uid string) (models.AccountDeletion, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	d, ok := s.deletions[uid]
+	return d, ok
+}
+
+// 取消申請（不論確認了沒）；回傳是否有申請
+func (s *Store) CancelDeletion(uid string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.deletions[uid]; !ok {
+		return false
+	}
+	delete(s.deletions, uid)
+	return true
+}
+
+// 已確認且緩衝期已過的帳號
+func (s *Store) DueDeletions(now time.Time) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []string
+	for uid, d := range s.deletions {
+		if d.ConfirmedAt != "" && !now.Before(parseISO(d.PurgeAt)) {
+			out = append(out, uid)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// 清除結果（各類資料處理了幾筆）；Media 是要刪掉的上傳檔
+type ErasureResult struct {
+	Posts         int      `json:"posts"`
+	Comments      int      `json:"comments"`
+	Likes         int      `json:"likes"`
+	Follows       int      `json:"follows"`
+	Boards        int      `json:"boards"`
+	Conversations int      `json:"conversations"`
+	Messages      int      `json:"messages"`
+	Anonymized    bool     `json:"anonymized"`
+	Media         []string `json:"-"`
+}
+
+// 清除 uid 的所有資料：profile、標籤、雙向追蹤與追蹤請求、按讚、貼文與留言（anonymize=true 時保留內容、拿掉作者）、
+// board 擁有者 / 管理員、對話成員與寄出的訊息、對話設定、封鎖、靜音、連結身分、刪除申請。
+// 回傳被刪掉的 profile（呼叫端寫稽核紀錄用）；library 快照與上傳檔由呼叫端刪除。
+func (s *Store) EraseAccount(uid string, anonymize bool) (ErasureResult, models.Profile) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res := ErasureResult{Anonymized: anonymize}
+	now := nowISO()
+	addMedia := func(u *string) {
+		if u != nil && strings.HasPrefix(*u, "/uploads/") {
+			res.Media = append(res.Media, *u)
+		}
+	}
+
+	// 貼文 / 留言
+	kept := make([]models.Post, 0, len(s.posts))
+	for _, p := range s.posts {
+		if p.Author.ID == uid {
+			res.Posts++
+			if !anonymize {
+				addMedia(p.ImageURL)
+				delete(s.postLikes, p.ID)
+				continue
+			}
+			p.Author = models.User{}
+		}
+		if p.Comments != nil {
+			comments := make([]models.Comment, 0, len(p.Comments))
+			for _, c := range p.Comments {
+				if c.Author.ID == uid {
+					res.Comments++
+					if !anonymize {
+						continue
+					}
+					c.Author = models.User{}
+				}
+				comments = append(comments, c)
+			}
+			p.Comments = comments
+		}
+		kept = append(kept, p)
+	}
+	s.posts = kept
+
+	// 按讚
+	for _, set := range s.postLikes {
+		if _, ok := set[uid]; ok {
+			delete(set, uid)
+			res.Likes++
+		}
+	}
+
+	delete(s.tags, uid)
+
+	// 追蹤（雙向）與追蹤請求
+	res.Follows = len(s.friends[uid])
+	delete(s.friends, uid)
+	for _, set := range s.friends {
+		if _, ok := set[uid]; ok {
+			delete(set, uid)
+			res.Follows++
+		}
+	}
+	s.rebuildFollowersLocked()
+	delete(s.followReq, uid)
+	for _, set := range s.followReq {
+		delete(set, uid)
+	}
+
+	// Profile 與索引
+	prof := s.profiles[uid]
+	addMedia(prof.AvatarURL)
+	if prof.Handle != "" && s.handles[prof.Handle] == uid {
+		delete(s.handles, prof.Handle)
+	}
+	delete(s.publicIDs, prof.PublicID)
+	delete(s.profiles, uid)
+
+	// Boards：擁有者由第一位管理員接手，沒有管理員就軟刪除（照一般復原期限清除）
+	for id, b := range s.boards {
+		if b.OwnerID != uid && !containsString(b.ModeratorIDs, uid) {
+			continue
+		}
+		b.ModeratorIDs = withoutString(b.ModeratorIDs, uid)
+		if b.OwnerID == uid {
+			b.OwnerID = ""
+			if len(b.ModeratorIDs) > 0 {
+				b.OwnerID, b.ModeratorIDs = b.ModeratorIDs[0], b.ModeratorIDs[1:]
+			} else if !b.Deleted {
+				b.Deleted = true
+				b.DeletedAt = now
+			}
+		}
+		b.UpdatedAt = now
+		s.boards[id] = b
+		res.Boards++
+	}
+
+	// 對話：移出成員；沒人了就整個對話刪掉
+	touched := map[string]struct{}{}
+	dropped := map[string]struct{}{}
+	for id, c := range s.conversations {
+		_, wasFormer := c.FormerMembers[uid]
+		if !containsString(c.MemberIDs, uid) && !wasFormer {
+			continue
+		}
+		res.Conversations++
+		c.MemberIDs = withoutString(c.MemberIDs, uid)
+		if len(c.MemberIDs) == 0 {
+			delete(s.conversations, id)
+			delete(s.msgIndex, id)
+			dropped[id] = struct{}{}
+			continue
+		}
+		hadAdmins := len(c.AdminIDs) > 0
+		c.AdminIDs = withoutString(c.AdminIDs, uid)
+		if hadAdmins && len(c.AdminIDs) == 0 {
+			c.AdminIDs = []string{c.MemberIDs[0]}
+		}
+		c.PendingFor = withoutString(c.PendingFor, uid)
+		c.DeclinedBy = withoutString(c.DeclinedBy, uid)
+		// copy-on-write：GetConversation 回傳的副本仍共用舊 map，不能原地修改
+		if _, ok := c.ReadCursors[uid]; ok {
+			cursors := make(map[string]models.ReadCursor, len(c.ReadCursors))
+			for k, v := range c.ReadCursors {
+				if k != uid {
+					cursors[k] = v
+				}
+			}
+			c.ReadCursors = cursors
+		}
+		if wasFormer {
+			former := make(map[string]models.FormerMember, len(c.FormerMembers))
+			for k, v := range c.FormerMembers {
+				if k != uid {
+					former[k] = v
+				}
+			}
+			c.FormerMembers = former
+		}
+		if c.CreatedBy == uid {
+			c.CreatedBy = ""
+		}
+		c.DirectKey = "" // 對方之後再私訊就是新的對話
+		s.conversations[id] = c
+		touched[id] = struct{}{}
+	}
+
+	// 訊息：寄出的改成沒有寄件者（delete 政策下同時收回內容）；系統訊息裡提到的也拿掉
+	// （contentJson 裡可能是身分鍵、PublicID，或合併前的舊識別碼）
+	refs := map[string]struct{}{uid: {}}
+	if prof.PublicID != "" {
+		refs[prof.PublicID] = struct{}{}
+	}
+	for id := range s.identities {
+		if s.canonicalLocked(id) == uid {
+			refs[id] = struct{}{}
+		}
+	}
+	for id, m := range s.messages {
+		if _, ok := dropped[m.ConversationID]; ok {
+			delete(s.messages, id)
+			continue
+		}
+		changed := false
+		if m.SenderID == uid {
+			m.SenderID = ""
+			if !anonymize && !m.Deleted {
+				collectUploads(m.ContentJson, &res.Media)
+				m.Deleted = true
+				m.DeletedAt = now
+				m.Text = ""
+				m.ContentSchema = ""
+				m.ContentJson = nil
+				m.EditHistory = nil
+			}
+			res.Messages++
+			changed = true
+		}
+		if containsString(m.HiddenFor, uid) {
+			m.HiddenFor = withoutString(m.HiddenFor, uid)
+			changed = true
+		}
+		if scrubbed, ok := scrubRef(m.ContentJson, refs); ok {
+			m.ContentJson, _ = scrubbed.(map[string]any)
+			changed = true
+		}
+		if changed {
+			s.messages[id] = m
+			touched[m.ConversationID] = struct{}{}
+		}
+	}
+	for id := range touched {
+		s.refreshLastMessageLocked(id)
+	}
+
+	delete(s.convSettings, uid)
+
+	// 封鎖（雙向）、靜音
+	delete(s.blocks, uid)
+	for _, set := range s.blocks {
+		delete(set, uid)
+	}
+	delete(s.mutes, uid)
+	for id, m := range s.mutes {
+		accounts := m.Accounts[:0:0]
+		for _, e := range m.Accounts {
+			if e.Value != uid {
+				accounts = append(accounts, e)
+			}
+		}
+		if len(accounts) != len(m.Accounts) {
+			m.Accounts = accounts
+			s.mutes[id] = m
+		}
+	}
+
+	// 推薦快取裡可能有這個人，全部重算
+	s.suggestions = make(map[string]suggestionCache)
+
+	for id, acc := range s.identities {
+		if acc == uid {
+			delete(s.identities, id)
+		}
+	}
+	delete(s.identities, uid)
+	delete(s.deletions, uid)
+	return res, prof
+}
+
+// 把 v 裡出現在 refs 的字串拿掉（陣列移除元素、單一值改成空字串）；回傳是否有變動
+func scrubRef(v any, refs map[string]struct{}) (any, bool) {
+	switch x := v.(type) {
+	case string:
+		if _, ok := refs[x]; ok {
+			return "", true
+		}
+	case []string: // 剛插入、還沒存檔重載過的系統訊息
+		out := make([]string, 0, len(x))
+		for _, s := range x {
+			if _, ok := refs[s]; !ok {
+				out = append(out, s)
+			}
+		}
+		if len(out) != len(x) {
+			return out, true
+		}
+	case []any:
+		out := make([]any, 0, len(x))
+		changed := false
+		for _, e := range x {
+			if s, ok := e.(string); ok {
+				if _, hit := refs[s]; hit {
+					changed = true
+					continue
+				}
+			}
+			e2, c := scrubRef(e, refs)
+			changed = changed || c
+			out = append(out, e2)
+		}
+		if changed {
+			return out, true
+		}
+	case map[string]any:
+		// 不原地修改：GetMessage / ListMessages 回傳的副本仍共用同一個 contentJson
+		var out map[string]any
+		for k, e := range x {
+			if e2, c := scrubRef(e, refs); c {
+				if out == nil {
+					out = make(map[string]any, len(x))
+					for k2, v2 := range x {
+						out[k2] = v2
+					}
+				}
+				out[k] = e2
+			}
+		}
+		if out != nil {
+			return out, true
+		}
+	}
+	return v, false
+}
+
+// 收集 contentJson 裡指向站內上傳檔的 URL
+func collectUploads(v any, out *[]string) {
+	switch x := v.(type) {
+	case string:
+		if strings.HasPrefix(x, "/uploads/") {
+			*out = append(*out, x)
+		}
+	case []any:
+		for _, e := range x {
+			collectUploads(e, out)
+		}
+	case map[string]any:
+		for _, e := range x {
+			collectUploads(e, out)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package store
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"local.dev/socialdemo-backend/internal/models"
+)
+
+// ===== 個人資料匯出 =====
+// 匯出紀錄存在 exports.json；zip 由 httpx 在背景產生。這裡另外提供打包時要用的資料快照。
+
+func (s *Store) LoadExports(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.exports == nil {
+		s.exports = make(map[string]models.DataExport)
+	}
+	_ = readJSONFile(path, &s.exports)
+	// 重新啟動前還沒做完的匯出不會再繼續
+	for id, e := range s.exports {
+		if e.Status == models.ExportPending {
+			e.Status = models.ExportFailed
+			e.Error = "interrupted"
+			s.exports[id] = e
+		}
+	}
+}
+
+func (s *Store) SaveExports(path string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_ = writeJSONFile(path, s.exports)
+}
+
+// 建立匯出；同一個人已經有進行中的就回傳那一筆（created=false）
+func (s *Store) CreateExport(uid string, now time.Time) (e models.DataExport, created bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, ex := range s.exports {
+		if ex.OwnerID == uid && ex.Status == models.ExportPending {
+			return ex, false
+		}
+	}
+	e = models.DataExport{
+		ID:        newID("exp"),
+		OwnerID:   uid,
+		Status:    models.ExportPending,
+		CreatedAt: now.Format(time.RFC3339),
+	}
+	s.exports[e.ID] = e
+	return e, true
+}
+
+// 打包結束：err == nil 代表成功，檔案保留到 now+retention
+func (s *Store) FinishExport(id string, size int64, err error, now time.Time, retention time.Duration) models.DataExport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.exports[id]
+	if !ok {
+		return e
+	}
+	e.CompletedAt = now.Format(time.RFC3339)
+	if err != nil {
+		e.Status = models.ExportFailed
+		e.Error = err.Error()
+	} else {
+		e.Status = models.ExportReady
+		e.Size = size
+		e.ExpiresAt = now.Add(retention).Format(time.RFC3339)
+	}
+	s.exports[id] = e
+	return e
+}
+
+// uid 是否有還在打包的匯出（清除帳號前要等它結束）
+func (s *Store) HasPendingExport(uid string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, e := range s.exports {
+		if e.OwnerID == uid && e.Status == models.ExportPending {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Store) GetExport(id string) (models.DataExport, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	e, ok := s.exports[id]
+	return e, ok
+}
+
+// uid 的匯出，新的在前
+func (s *Store) ListExports(uid string) []models.DataExport {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]models.DataExport, 0)
+	for _, e := range s.exports {
+		if e.OwnerID == uid {
+			out = append(out, e)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt > out[j].CreatedAt })
+	return out
+}
+
+// 移除過期的匯出紀錄（ownerID 非空時改成移除這個人的全部紀錄），回傳被移除的 ID；檔案由呼叫端刪除
+func (s *Store) DropExports(now time.Time, ownerID string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var ids []string
+	for id, e := range s.exports {
+		expired := e.ExpiresAt != "" && !now.Before(parseISO(e.ExpiresAt))
+		if (ownerID != "" && e.OwnerID == ownerID) || (ownerID == "" && expired) {
+			delete(s.exports, id)
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// 自己在某篇貼文底下的留言
+type ExportedComment struct {
+	PostID string `json:"postId"`
+	models.Comment
+}
+
+// 匯出用：自己發的貼文、在別人貼文底下的留言、按讚過的貼文、擁有或管理的 boards
+type UserContent struct {
+	Posts      []models.Post
+	Comments   []ExportedComment
+	LikedPosts []string
+	Boards     []models.Board
+}
+
+func (s *Store) UserContent(uid string) UserContent {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := UserContent{
+		Posts:      make([]models.Post, 0),
+		Comments:   make([]ExportedComment, 0),
+		LikedPosts: make([]string, 0),
+		Boards:     make([]models.Board, 0),
+	}
+	for _, p := range s.posts {
+		if p.Author.ID == uid {
+			cp := p
+			cp.Comments = append([]models.Comment(nil), p.Comments...)
+			cp.LikeCount = len(s.postLikes[p.ID])
+			_, cp.LikedByMe = s.postLikes[p.ID][uid]
+			out.Posts = append(out.Posts, cp)
+			continue
+		}
+		for _, c := range p.Comments {
+			if c.Author.ID == uid {
+				out.Comments = append(out.Comments, ExportedComment{PostID: p.ID, Comment: c})
+			}
+		}
+	}
+	for postID, set := range s.postLikes {
+		if _, ok := set[uid]; ok {
+			out.LikedPosts = append(out.LikedPosts, postID)
+		}
+	}
+	sort.Strings(out.LikedPosts)
+	for _, b := range s.boards {
+		if b.OwnerID == uid || containsString(b.ModeratorIDs, uid) {
+			out.Boards = append(out.Boards, b)
+		}
+	}
+	sort.Slice(out.Boards, func(i, j int) bool { return out.Boards[i].CreatedAt < out.Boards[j].CreatedAt })
+	return out
+}
+
+// 匯出用：參與過的對話（含已離開的）與自己看得到的訊息（由舊到新）
+type UserConversation struct {
+	Conversation models.Conversation
+	Messages     []models.Message
+}
+
+func (s *Store) UserConversations(uid string) []UserConversation {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make([]UserConversation, 0)
+	for id, c := range s.conversations {
+		former, wasFormer := c.FormerMembers[uid]
+		if !containsString(c.MemberIDs, uid) && !wasFormer {
+			continue
+		}
+		var until models.ReadCursor
+		if wasFormer && !containsString(c.MemberIDs, uid) {
+			// 離開的成員只看得到離開當下為止的訊息
+			until = models.ReadCursor{MessageID: former.LastMessageID, MessageAt: s.messages[former.LastMessageID].CreatedAt}
+			if until.MessageAt == "" {
+				until.MessageAt = former.LeftAt
+			}
+		}
+		if st, ok := s.convSettings[uid][id]; ok {
+			c.Settings = &st
+		}
+		msgs := make([]models.Message, 0, len(s.msgIndex[id]))
+		for _, mid := range s.msgIndex[id] {
+			m := s.messages[mid]
+			if until.MessageAt != "" && compareMessage(m, until) > 0 {
+				break
+			}
+			if containsString(m.HiddenFor, uid) {
+				continue
+			}
+			m.HiddenFor = nil
+			if m.Deleted {
+				m.Text, m.ContentSchema, m.ContentJson, m.EditHistory = "", "", nil, nil
+			}
+			msgs = append(msgs, m)
+		}
+		out = append(out, UserConversation{Conversation: c, Messages: msgs})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Conversation.CreatedAt < out[j].Conversation.CreatedAt })
+	return out
+}
+
+// 匯出用：這個人上傳、還被引用的站內檔案（大頭貼、貼文圖片、自己寄出的訊息附件）
+func (s *Store) UserUploads(uid string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var urls []string
+	if p, ok := s.profiles[uid]; ok && p.AvatarURL != nil {
+		urls = append(urls, *p.AvatarURL)
+	}
+	for _, p := range s.posts {
+		if p.Author.ID == uid && p.ImageURL != nil {
+			urls = append(urls, *p.ImageURL)
+		}
+	}
+	for _, m := range s.messages {
+		if m.SenderID == uid && !m.Deleted {
+			collectUploads(m.ContentJson, &urls)
+		}
+	}
+
+	seen := map[string]struct{}{}
+	out := make([]string, 0, len(urls))
+	for _, u := range urls {
+		if _, dup := seen[u]; dup || !strings.HasPrefix(u, "/uploads/") {
+			continue
+		}
+		seen[u] = struct{}{}
+		out = append(out, u)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,131 @@
+package store
+
+import "sort"
+
+// ===== 私人帳號：追蹤請求 =====
+
+func (s *Store) LoadFollowRequests(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.followReq == nil {
+		s.followReq = make(map[string]map[string]string)
+	}
+	_ = readJSONFile(path, &s.followReq)
+}
+
+func (s *Store) SaveFollowRequests(path string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_ = writeJSONFile(path, s.followReq)
+}
+
+// 對私人帳號送出追蹤請求（已追蹤或已申請過就不動）
+func (s *Store) RequestFollow(uid, target string) {
+	if uid == "" || target == "" || uid == target {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.friends[uid][target]; ok {
+		return
+	}
+	if s.followReq == nil {
+		s.followReq = make(map[string]map[string]string)
+	}
+	if s.followReq[target] == nil {
+		s.followReq[target] = make(map[string]string)
+	}
+	if _, ok := s.followReq[target][uid]; !ok {
+		s.followReq[target][uid] = nowISO()
+	}
+}
+
+// 移除請求（取消 / 拒絕）；回傳是否真的有這筆
+func (s *Store) RemoveFollowRequest(target, requester string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.removeFollowRequestLocked(target, requester)
+}
+
+func (s *Store) removeFollowRequestLocked(target, requester string) bool {
+	if _, ok := s.followReq[target][requester]; !ok {
+		return false
+	}
+	delete(s.followReq[target], requester)
+	if len(s.followReq[target]) == 0 {
+		delete(s.followReq, target)
+	}
+	return true
+}
+
+// 核准：請求轉成追蹤關係；沒有這筆請求回傳 false
+func (s *Store) ApproveFollowRequest(target, requester string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.removeFollowRequestLocked(target, requester) {
+		return false
+	}
+	s.followLocked(requester, target)
+	return true
+}
+
+// 核准全部（私人帳號改成公開時）；回傳核准了幾筆
+func (s *Store) ApproveAllFollowRequests(target string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for requester := range s.followReq[target] {
+		s.followLocked(requester, target)
+		n++
+	}
+	delete(s.followReq, target)
+	return n
+}
+
+func (s *Store) HasFollowRequest(requester, target string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.followReq[target][requester]
+	return ok
+}
+
+type FollowRequest struct {
+	UserID      string `json:"userId"`
+	RequestedAt string `json:"requestedAt"`
+}
+
+// 等待 target 核准的請求，舊 → 新
+func (s *Store) ListFollowRequests(target string) []FollowRequest {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]FollowRequest, 0, len(s.followReq[target]))
+	for id, at := range s.followReq[target] {
+		out = append(out, FollowRequest{UserID: id, RequestedAt: at})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].RequestedAt != out[j].RequestedAt {
+			return out[i].RequestedAt < out[j].RequestedAt
+		}
+		return out[i].UserID < out[j].UserID
+	})
+	return out
+}
+
+// 私人帳號的貼文 viewer 看不到（不是本人、也不是粉絲）；呼叫端需持有鎖
+func (s *Store) privateHiddenLocked(authorID, viewerUID string) bool {
+	if authorID == viewerUID {
+		return false
+	}
+	if p, ok := s.profiles[authorID]; !ok || !p.Private {
+		return false
+	}
+	_, follows := s.friends[viewerUID][authorID]
+	return !follows
+}
+
+// 給 handler 用：viewer 能不能看 uid 的貼文 / 追蹤名單
+func (s *Store) CanViewPrivate(uid, viewerUID string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return !s.privateHiddenLocked(uid, viewerUID)
+}
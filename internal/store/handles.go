@@ -0,0 +1,267 @@
+package store
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"local.dev/socialdemo-backend/internal/models"
+)
+
+// ===== Handle 與公開 ID =====
+// 身分鍵（email 小寫 / Firebase UID）只在後端使用；對外一律用 PublicID（"u_" + 隨機碼），
+// 另外每個人可以設定一個唯一的 @handle。兩個索引都由 profiles 推出，不另外存檔。
+
+const (
+	handleMinLen       = 3
+	handleMaxLen       = 30
+	handleChangeEvery  = 7 * 24 * time.Hour  // 兩次改名之間至少隔這麼久
+	handleReservedFor  = 14 * 24 * time.Hour // 換掉的舊 handle 保留給原主人這麼久
+	maxHandleHistory   = 20
+	publicIDPrefix     = "u_"
+	publicIDRandomSize = 8
+)
+
+var (
+	ErrHandleInvalid  = errors.New("handle must be 3-30 characters of a-z, 0-9, '_' or '.'")
+	ErrHandleTaken    = errors.New("handle is already taken")
+	ErrHandleReserved = errors.New("handle is reserved")
+	ErrHandleCooldown = errors.New("handle was changed too recently")
+)
+
+// 系統用字，任何人都不能拿
+var reservedHandles = map[string]struct{}{
+	"admin": {}, "administrator": {}, "root": {}, "system": {}, "support": {}, "help": {},
+	"staff": {}, "official": {}, "moderator": {}, "mod": {}, "me": {}, "api": {},
+	"users": {}, "boards": {}, "posts": {}, "settings": {}, "login": {}, "logout": {},
+	"null": {}, "undefined": {},
+}
+
+// 去掉開頭的 @ 並轉小寫
+func NormalizeHandle(h string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(h), "@"))
+}
+
+// a-z 0-9 _ .；不能以 . 開頭 / 結尾、不能連續兩個 .、不能全是數字
+func validHandle(h string) bool {
+	if len(h) < handleMinLen || len(h) > handleMaxLen {
+		return false
+	}
+	if h[0] == '.' || h[len(h)-1] == '.' || strings.Contains(h, "..") {
+		return false
+	}
+	digits := true
+	for _, c := range h {
+		switch {
+		case c >= '0' && c <= '9':
+		case c >= 'a' && c <= 'z', c == '_', c == '.':
+			digits = false
+		default:
+			return false
+		}
+	}
+	return !digits
+}
+
+func newPublicID() string {
+	var b [publicIDRandomSize]byte
+	_, _ = rand.Read(b[:])
+	return publicIDPrefix + hex.EncodeToString(b[:])
+}
+
+// 載入後重建索引，順便幫舊資料補上 PublicID（只出現在貼文 / 留言 / 追蹤裡、沒有 profile 的人也補一份）；
+// 呼叫端需持有鎖
+func (s *Store) rebuildHandlesLocked() (assigned int) {
+	s.handles = make(map[string]string)
+	s.publicIDs = make(map[string]string)
+	known := func(uid string) {
+		if _, ok := s.profiles[uid]; !ok && uid != "" {
+			s.profiles[uid] = models.Profile{ID: uid}
+		}
+	}
+	for _, p := range s.posts {
+		known(p.Author.ID)
+		for _, c := range p.Comments {
+			known(c.Author.ID)
+		}
+	}
+	for uid, set := range s.friends {
+		known(uid)
+		for id := range set {
+			known(id)
+		}
+	}
+	for uid := range s.tags {
+		known(uid)
+	}
+	for uid, p := range s.profiles {
+		if p.PublicID == "" {
+			p.PublicID = s.unusedPublicIDLocked()
+			s.profiles[uid] = p
+			assigned++
+		}
+		s.publicIDs[p.PublicID] = uid
+		if p.Handle != "" {
+			s.handles[p.Handle] = uid
+		}
+	}
+	return assigned
+}
+
+func (s *Store) unusedPublicIDLocked() string {
+	for {
+		id := newPublicID()
+		if _, used := s.publicIDs[id]; !used {
+			return id
+		}
+	}
+}
+
+// 確保 uid 有 PublicID（沒有 profile 就建一份空的）；回傳值 created=true 表示 profiles 有變動需要存檔。
+// 只給 ensureAccount（已登入的本人）用，回應裡的其他使用者一律走唯讀的 PublicID
+func (s *Store) EnsurePublicID(uid string) (publicID string, created bool) {
+	if uid == "" {
+		return "", false
+	}
+	s.mu.RLock()
+	p, ok := s.profiles[uid]
+	s.mu.RUnlock()
+	if ok && p.PublicID != "" {
+		return p.PublicID, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok = s.profiles[uid]
+	if ok && p.PublicID != "" {
+		return p.PublicID, false
+	}
+	if !ok {
+		p = models.Profile{ID: uid}
+	}
+	p.PublicID = s.unusedPublicIDLocked()
+	s.profiles[uid] = p
+	s.publicIDs[p.PublicID] = uid
+	return p.PublicID, true
+}
+
+// 回應用：身分鍵 → PublicID。唯讀：沒有 profile 的（已清除、已合併走的舊身分鍵…）回傳空字串
+func (s *Store) PublicID(uid string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.profiles[uid].PublicID
+}
+
+// 請求用：接受 "@handle"、PublicID（含合併前的舊 PublicID）；身分鍵（email / Firebase UID）
+// 只在就是 self 本人時接受，不能拿來探測別人的 email 有沒有帳號。找不到這個人時回傳 false
+func (s *Store) ResolveUserRef(ref, self string) (string, bool) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return "", false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if strings.HasPrefix(ref, "@") {
+		uid, ok := s.handles[NormalizeHandle(ref)]
+		return uid, ok
+	}
+	if uid, ok := s.publicIDs[ref]; ok {
+		return uid, true
+	}
+	if strings.HasPrefix(ref, publicIDPrefix) {
+		if _, ok := s.identities[ref]; ok {
+			return s.canonicalLocked(ref), true // 已合併帳號的舊 PublicID
+		}
+		return "", false
+	}
+	if self != "" && s.canonicalLocked(ref) == self {
+		return self, true
+	}
+	return "", false
+}
+
+// 設定 / 更換 handle（空字串 = 移除）。舊的 handle 記到 HandleHistory，
+// 保留期間內只有原主人能再拿回來。
+func (s *Store) SetHandle(uid, handle string) (models.Profile, error) {
+	h := NormalizeHandle(handle)
+	if h != "" && !validHandle(h) {
+		return models.Profile{}, ErrHandleInvalid
+	}
+	if _, ok := reservedHandles[h]; ok {
+		return models.Profile{}, ErrHandleReserved
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.profiles[uid]
+	if !ok {
+		return models.Profile{}, ErrAccountUnknown
+	}
+	if p.Handle == h {
+		return p, nil
+	}
+	if owner, taken := s.handles[h]; h != "" && taken && owner != uid {
+		return models.Profile{}, ErrHandleTaken
+	}
+	now := time.Now().UTC()
+	if h != "" && s.handleHeldLocked(h, uid, now) {
+		return models.Profile{}, ErrHandleReserved
+	}
+	if p.Handle != "" && len(p.HandleHistory) > 0 {
+		last := parseISO(p.HandleHistory[len(p.HandleHistory)-1].ReleasedAt)
+		if now.Sub(last) < handleChangeEvery {
+			return models.Profile{}, ErrHandleCooldown
+		}
+	}
+
+	if p.Handle != "" {
+		delete(s.handles, p.Handle)
+		p.HandleHistory = append(p.HandleHistory, models.HandleChange{Handle: p.Handle, ReleasedAt: now.Format(time.RFC3339)})
+		if len(p.HandleHistory) > maxHandleHistory {
+			p.HandleHistory = p.HandleHistory[len(p.HandleHistory)-maxHandleHistory:]
+		}
+	}
+	p.Handle = h
+	if h != "" {
+		s.handles[h] = uid
+	}
+	s.profiles[uid] = p
+	return p, nil
+}
+
+// h 還在別人的保留期內
+func (s *Store) handleHeldLocked(h, uid string, now time.Time) bool {
+	for other, p := range s.profiles {
+		if other == uid {
+			continue
+		}
+		for _, c := range p.HandleHistory {
+			if c.Handle == h && now.Sub(parseISO(c.ReleasedAt)) < handleReservedFor {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// 查 handle 能不能用（給前端即時檢查）
+func (s *Store) HandleAvailable(uid, handle string) error {
+	h := NormalizeHandle(handle)
+	if !validHandle(h) {
+		return ErrHandleInvalid
+	}
+	if _, ok := reservedHandles[h]; ok {
+		return ErrHandleReserved
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if owner, taken := s.handles[h]; taken && owner != uid {
+		return ErrHandleTaken
+	}
+	if s.handleHeldLocked(h, uid, time.Now().UTC()) {
+		return ErrHandleReserved
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package store
+
+import (
+	"strings"
+	"time"
+
+	"local.dev/socialdemo-backend/internal/models"
+	"local.dev/socialdemo-backend/internal/msgtypes"
+)
+
+// ===== 靜音（帳號 / 標籤 / 關鍵字） =====
+// 跟封鎖不同：只影響自己的 feed，對方不受任何限制；每筆可以設到期時間。
+
+func (s *Store) LoadMutes(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.mutes == nil {
+		s.mutes = make(map[string]models.MuteList)
+	}
+	_ = readJSONFile(path, &s.mutes)
+}
+
+func (s *Store) SaveMutes(path string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_ = writeJSONFile(path, s.mutes)
+}
+
+// 只留下還沒到期的
+func activeMutes(list []models.MuteEntry, now time.Time) []models.MuteEntry {
+	out := make([]models.MuteEntry, 0, len(list))
+	for _, e := range list {
+		if e.ExpiresAt == "" || parseISO(e.ExpiresAt).After(now) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+func (s *Store) GetMutes(uid string) models.MuteList {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	m := s.mutes[uid]
+	now := time.Now().UTC()
+	m.Accounts = activeMutes(m.Accounts, now)
+	m.Tags = activeMutes(m.Tags, now)
+	m.Keywords = activeMutes(m.Keywords, now)
+	return m
+}
+
+// 整份取代（到期的順便清掉）
+func (s *Store) SetMutes(uid string, m models.MuteList) models.MuteList {
+	now := time.Now().UTC()
+	m.Accounts = activeMutes(m.Accounts, now)
+	m.Tags = activeMutes(m.Tags, now)
+	m.Keywords = activeMutes(m.Keywords, now)
+	m.UpdatedAt = now.Format(time.RFC3339)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.mutes == nil {
+		s.mutes = make(map[string]models.MuteList)
+	}
+	s.mutes[uid] = m
+	return m
+}
+
+// 標籤比對忽略大小寫與開頭的 #
+func muteTag(t string) string { return strings.TrimPrefix(normalizeTag(t), "#") }
+
+// 列表用：viewer 目前生效的靜音條件
+type muteFilter struct {
+	accounts map[string]struct{}
+	tags     map[string]struct{}
+	keywords []string // 已 Fold
+}
+
+func (s *Store) muteFilterLocked(viewerUID string) muteFilter {
+	f := muteFilter{accounts: map[string]struct{}{}, tags: map[string]struct{}{}}
+	m, ok := s.mutes[viewerUID]
+	if viewerUID == "" || !ok {
+		return f
+	}
+	now := time.Now().UTC()
+	for _, e := range activeMutes(m.Accounts, now) {
+		f.accounts[e.Value] = struct{}{}
+	}
+	for _, e := range activeMutes(m.Tags, now) {
+		f.tags[muteTag(e.Value)] = struct{}{}
+	}
+	for _, e := range activeMutes(m.Keywords, now) {
+		if k := msgtypes.Fold(strings.TrimSpace(e.Value)); k != "" {
+			f.keywords = append(f.keywords, k)
+		}
+	}
+	return f
+}
+
+// 貼文是否被靜音；checkAuthor=false 時不看帳號（直接進對方個人頁時仍看得到）
+func (f muteFilter) hides(p models.Post, checkAuthor bool) bool {
+	if checkAuthor {
+		if _, ok := f.accounts[p.Author.ID]; ok {
+			return true
+		}
+	}
+	for _, t := range p.Tags {
+		if _, ok := f.tags[muteTag(t)]; ok {
+			return true
+		}
+	}
+	if len(f.keywords) == 0 {
+		return false
+	}
+	text := msgtypes.Fold(p.Text + "\n" + strings.Join(p.Tags, " "))
+	for _, k := range f.keywords {
+		if strings.Contains(text, k) {
+			return true
+		}
+	}
+	return false
+}
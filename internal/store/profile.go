@@ -12,7 +12,32 @@ func (s *Store) GetProfile(uid string) (models.Profile, bool) {
 	return p, ok
 }
 
-// 新增或更新 Profile（僅覆蓋有提供的欄位）
+// PATCH /me 用：在鎖內拿目前的 Profile 交給 fn 算出新版本（fn 回傳錯誤就不寫入）。
+// 伺服器管理的欄位（PublicID / handle / 徽章）與計算欄位不受 fn 影響。
+func (s *Store) UpdateProfile(uid string, fn func(cur models.Profile) (models.Profile, error)) (models.Profile, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cur, ok := s.profiles[uid]
+	if !ok {
+		cur = models.Profile{ID: uid, PublicID: s.unusedPublicIDLocked()}
+		s.publicIDs[cur.PublicID] = uid
+	}
+	next, err := fn(cur)
+	if err != nil {
+		return cur, err
+	}
+	next.ID = uid
+	next.PublicID = cur.PublicID
+	next.Handle, next.HandleHistory = cur.Handle, cur.HandleHistory
+	next.VerifiedBadge = cur.VerifiedBadge
+	next.FollowerCount, next.FollowingCount = 0, 0
+	next.FollowedByMe, next.FollowsMe, next.Mutual, next.FollowRequested = false, false, false, false
+	s.profiles[uid] = next
+	return next, nil
+}
+
+// 新增或更新 Profile（僅覆蓋有提供的欄位；boolean 會直接覆蓋，部分更新請用 UpdateProfile）
 func (s *Store) UpsertProfile(p models.Profile) models.Profile {
 	if p.ID == "" {
 		return p
@@ -20,9 +45,16 @@ func (s *Store) UpsertProfile(p models.Profile) models.Profile {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	// 計算欄位不存檔
+	p.FollowerCount, p.FollowingCount = 0, 0
+	p.FollowedByMe, p.FollowsMe, p.Mutual, p.FollowRequested = false, false, false, false
+
 	ex, ok := s.profiles[p.ID]
 	if !ok {
-		// 新增
+		// 新增（handle 走 SetHandle，PublicID 由後端產生）
+		p.Handle, p.HandleHistory = "", nil
+		p.PublicID = s.unusedPublicIDLocked()
+		s.publicIDs[p.PublicID] = p.ID
 		s.profiles[p.ID] = p
 		return p
 	}
@@ -46,11 +78,32 @@ func (s *Store) UpsertProfile(p models.Profile) models.Profile {
 	if p.LineId != nil {
 		ex.LineId = p.LineId
 	}
-	// 這三個是 boolean（非指標），直接覆蓋
+	if p.BirthdayVisibility != "" {
+		ex.BirthdayVisibility = p.BirthdayVisibility
+	}
+	// 這幾個是 boolean（非指標），直接覆蓋
 	ex.ShowInstagram = p.ShowInstagram
 	ex.ShowFacebook = p.ShowFacebook
 	ex.ShowLine = p.ShowLine
+	ex.MutualsSeeContacts = p.MutualsSeeContacts
+	ex.HideLastSeen = p.HideLastSeen
+	// Private 不在這裡更新：沒帶這個欄位的更新會被當成 false，改走 UpdateProfile
+	// VerifiedBadge 不在這裡更新，改走 SetVerifiedBadge（管理員專用）
 
 	s.profiles[p.ID] = ex
 	return ex
 }
+
+// 設定 / 移除認證徽章（badge 為空字串 = 移除）；Profile 不存在時回傳 false
+func (s *Store) SetVerifiedBadge(uid, badge string) (models.Profile, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.profiles[uid]
+	if !ok {
+		return p, false
+	}
+	p.VerifiedBadge = badge
+	s.profiles[uid] = p
+	return p, true
+}
@@ -2,6 +2,7 @@ package store
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -11,13 +12,17 @@ import (
 	"time"
 
 	"local.dev/socialdemo-backend/internal/models"
+	"local.dev/socialdemo-backend/internal/msgtypes"
 )
 
 type Store struct {
 	mu        sync.RWMutex
 	posts     []models.Post
 	tags      map[string][]string            // userId -> tags
+	mutes     map[string]models.MuteList     // userId -> 靜音的帳號 / 標籤 / 關鍵字
 	friends   map[string]map[string]struct{} // userId -> set(friendId)
+	followers map[string]map[string]struct{} // 🔻 新增：反向索引 userId -> set(追蹤他的人)，由 friends 推出，不存檔
+	followReq map[string]map[string]string   // 🔻 新增：私人帳號 target -> requester -> 申請時間
 	profiles  map[string]models.Profile      // userId -> profile (定義在 profile.go 的 Get/Upsert 使用)
 	postLikes map[string]map[string]struct{} // postId -> set(uid)
 
@@ -25,12 +30,24 @@ type Store struct {
 	boards        map[string]models.Board
 	conversations map[string]models.Conversation
 	messages      map[string]models.Message
+	msgIndex      map[string][]string                               // convId -> message IDs（依 createdAt、ID 由舊到新）
+	convSettings  map[string]map[string]models.ConversationSettings // uid -> convId -> 自己的對話設定
+	blocks        map[string]map[string]string                      // blocker -> blocked -> 封鎖時間
+	suggestions   map[string]suggestionCache                        // uid -> 推薦追蹤（背景工作算好，不存檔）
+	handles       map[string]string                                 // handle -> uid（由 profiles 推出，不存檔）
+	publicIDs     map[string]string                                 // PublicID -> uid（由 profiles 推出，不存檔）
+	identities    map[string]string                                 // 識別碼（email / UID / 舊 PublicID）-> 主帳號
+	deletions     map[string]models.AccountDeletion                 // uid -> 刪除帳號申請
+	exports       map[string]models.DataExport                      // exportId -> 個人資料匯出
 }
 
 func NewStore() *Store {
 	return &Store{
 		tags:      map[string][]string{},
+		mutes:     map[string]models.MuteList{},
 		friends:   map[string]map[string]struct{}{},
+		followers: map[string]map[string]struct{}{},
+		followReq: map[string]map[string]string{},
 		profiles:  map[string]models.Profile{},
 		postLikes: map[string]map[string]struct{}{},
 
@@ -38,6 +55,15 @@ func NewStore() *Store {
 		boards:        map[string]models.Board{},
 		conversations: map[string]models.Conversation{},
 		messages:      map[string]models.Message{},
+		msgIndex:      map[string][]string{},
+		convSettings:  map[string]map[string]models.ConversationSettings{},
+		blocks:        map[string]map[string]string{},
+		suggestions:   map[string]suggestionCache{},
+		handles:       map[string]string{},
+		publicIDs:     map[string]string{},
+		identities:    map[string]string{},
+		deletions:     map[string]models.AccountDeletion{},
+		exports:       map[string]models.DataExport{},
 	}
 }
 
@@ -69,6 +95,16 @@ func containsString(list []string, v string) bool {
 	return false
 }
 
+func withoutString(list []string, v string) []string {
+	out := make([]string, 0, len(list))
+	for _, x := range list {
+		if x != v {
+			out = append(out, x)
+		}
+	}
+	return out
+}
+
 func readJSONFile[T any](path string, out *T) error {
 	b, err := os.ReadFile(path)
 	if err != nil {
@@ -91,6 +127,9 @@ func (s *Store) LoadBoards(path string) {
 		s.boards = make(map[string]models.Board)
 	}
 	_ = readJSONFile(path, &s.boards)
+	if s.backfillBoardDeletedAt() {
+		s.SaveBoards(path)
+	}
 }
 
 func (s *Store) LoadDM(conversationsPath, messagesPath string) {
@@ -102,6 +141,8 @@ func (s *Store) LoadDM(conversationsPath, messagesPath string) {
 	}
 	_ = readJSONFile(conversationsPath, &s.conversations)
 	_ = readJSONFile(messagesPath, &s.messages)
+	s.rebuildMessageIndex()
+	s.backfillConversationKinds()
 }
 
 func (s *Store) SaveBoards(path string)        { _ = writeJSONFile(path, s.boards) }
@@ -249,6 +290,9 @@ func (s *Store) List(tab string, tags []string, viewerUID string) []models.Post
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
+	hidden := s.hiddenUsersLocked(viewerUID) // 封鎖關係的另一方
+	muted := s.muteFilterLocked(viewerUID)
+
 	var base []models.Post
 	if len(tags) > 0 {
 		tagset := map[string]struct{}{}
@@ -256,6 +300,9 @@ func (s *Store) List(tab string, tags []string, viewerUID string) []models.Post
 			tagset[strings.ToLower(strings.TrimSpace(t))] = struct{}{}
 		}
 		for _, p := range s.posts {
+			if _, blocked := hidden[p.Author.ID]; blocked || s.inDeletedBoardLocked(p) || muted.hides(p, true) || s.privateHiddenLocked(p.Author.ID, viewerUID) {
+				continue
+			}
 			for _, pt := range p.Tags {
 				if _, ok := tagset[strings.ToLower(pt)]; ok {
 					base = append(base, p)
@@ -264,12 +311,16 @@ func (s *Store) List(tab string, tags []string, viewerUID string) []models.Post
 			}
 		}
 	} else {
-		base = append(base, s.posts...)
+		for _, p := range s.posts {
+			if _, blocked := hidden[p.Author.ID]; !blocked && !s.inDeletedBoardLocked(p) && !muted.hides(p, true) && !s.privateHiddenLocked(p.Author.ID, viewerUID) {
+				base = append(base, p)
+			}
+		}
 	}
 
 	out := make([]models.Post, 0, len(base))
 	for _, p := range base {
-		out = append(out, s.Decorate(p, viewerUID))
+		out = append(out, s.Decorate(withoutHiddenComments(p, hidden), viewerUID))
 	}
 
 	if tab == "hot" {
@@ -320,9 +371,15 @@ func (s *Store) UserPosts(uid, viewerUID string) []models.Post {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	var out []models.Post
+	hidden := s.hiddenUsersLocked(viewerUID)
+	if _, blocked := hidden[uid]; blocked || s.privateHiddenLocked(uid, viewerUID) {
+		return out
+	}
+	muted := s.muteFilterLocked(viewerUID)
 	for _, p := range s.posts {
-		if p.Author.ID == uid {
-			out = append(out, s.Decorate(p, viewerUID))
+		// 主動點進個人頁：帳號靜音不擋，標籤 / 關鍵字靜音照樣生效
+		if p.Author.ID == uid && !s.inDeletedBoardLocked(p) && !muted.hides(p, false) {
+			out = append(out, s.Decorate(withoutHiddenComments(p, hidden), viewerUID))
 		}
 	}
 	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt > out[j].CreatedAt })
@@ -386,18 +443,21 @@ func (s *Store) GetFriends(uid string) []string {
 	return out
 }
 
+// uid 是否追蹤 target
+func (s *Store) IsFollowing(uid, target string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.friends[uid][target]
+	return ok
+}
+
 func (s *Store) Follow(uid, target string) {
 	if uid == "" || target == "" || uid == target {
 		return
 	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	m := s.friends[uid]
-	if m == nil {
-		m = make(map[string]struct{})
-		s.friends[uid] = m
-	}
-	m[target] = struct{}{}
+	s.followLocked(uid, target)
 }
 
 func (s *Store) Unfollow(uid, target string) {
@@ -406,11 +466,65 @@ func (s *Store) Unfollow(uid, target string) {
 	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	m := s.friends[uid]
-	if m == nil {
-		return
+	s.unfollowLocked(uid, target)
+}
+
+// friends 與 followers 反向索引一起更新（呼叫端需持有寫鎖）
+func (s *Store) followLocked(uid, target string) {
+	if s.friends[uid] == nil {
+		s.friends[uid] = make(map[string]struct{})
+	}
+	s.friends[uid][target] = struct{}{}
+	if s.followers == nil {
+		s.followers = make(map[string]map[string]struct{})
+	}
+	if s.followers[target] == nil {
+		s.followers[target] = make(map[string]struct{})
+	}
+	s.followers[target][uid] = struct{}{}
+}
+
+// 有追蹤才移除；回傳是否有變動
+func (s *Store) unfollowLocked(uid, target string) bool {
+	if _, ok := s.friends[uid][target]; !ok {
+		return false
 	}
-	delete(m, target)
+	delete(s.friends[uid], target)
+	delete(s.followers[target], uid)
+	return true
+}
+
+// 載入 friends.json 後重建 followers 反向索引（呼叫端需持有寫鎖）
+func (s *Store) rebuildFollowersLocked() {
+	s.followers = make(map[string]map[string]struct{})
+	for uid, set := range s.friends {
+		for target := range set {
+			if s.followers[target] == nil {
+				s.followers[target] = make(map[string]struct{})
+			}
+			s.followers[target][uid] = struct{}{}
+		}
+	}
+}
+
+// 追蹤 uid 的人（依 ID 排序）
+func (s *Store) GetFollowers(uid string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	set := s.followers[uid]
+	out := make([]string, 0, len(set))
+	for id := range set {
+		out = append(out, id)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// 粉絲數 / 追蹤中人數
+func (s *Store) FollowCounts(uid string) (followers, following int) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.followers[uid]), len(s.friends[uid])
 }
 
 // ===== likes =====
@@ -471,11 +585,19 @@ func (s *Store) ListByAuthors(authors []string, tags []string, viewerUID string)
 
 	// ✅ 用空 slice，而不是 nil
 	out := make([]models.Post, 0)
+	hidden := s.hiddenUsersLocked(viewerUID)
+	muted := s.muteFilterLocked(viewerUID)
 
 	for _, p := range s.posts {
 		if _, ok := authorSet[p.Author.ID]; !ok {
 			continue
 		}
+		if _, blocked := hidden[p.Author.ID]; blocked || muted.hides(p, true) || s.privateHiddenLocked(p.Author.ID, viewerUID) {
+			continue
+		}
+		if s.inDeletedBoardLocked(p) {
+			continue
+		}
 		if len(tagSet) > 0 {
 			match := false
 			for _, pt := range p.Tags {
@@ -488,7 +610,7 @@ func (s *Store) ListByAuthors(authors []string, tags []string, viewerUID string)
 				continue
 			}
 		}
-		out = append(out, s.Decorate(p, viewerUID))
+		out = append(out, s.Decorate(withoutHiddenComments(p, hidden), viewerUID))
 	}
 
 	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt > out[j].CreatedAt })
@@ -513,10 +635,15 @@ func (s *Store) ListByBoard(boardID string, tags []string, viewerUID string) []m
 	}
 
 	out := make([]models.Post, 0)
+	hidden := s.hiddenUsersLocked(viewerUID)
+	muted := s.muteFilterLocked(viewerUID)
 	for _, p := range s.posts {
 		if p.BoardID != boardID {
 			continue
 		}
+		if _, blocked := hidden[p.Author.ID]; blocked || muted.hides(p, true) || s.privateHiddenLocked(p.Author.ID, viewerUID) {
+			continue
+		}
 		if len(tagSet) > 0 {
 			match := false
 			for _, pt := range p.Tags {
@@ -529,7 +656,7 @@ func (s *Store) ListByBoard(boardID string, tags []string, viewerUID string) []m
 				continue
 			}
 		}
-		out = append(out, s.Decorate(p, viewerUID))
+		out = append(out, s.Decorate(withoutHiddenComments(p, hidden), viewerUID))
 	}
 
 	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt > out[j].CreatedAt })
@@ -538,6 +665,15 @@ func (s *Store) ListByBoard(boardID string, tags []string, viewerUID string) []m
 
 // ===== Boards =====
 
+// 貼文所屬的 board 已被（軟）刪除 → 不出現在任何 feed（呼叫端需持有鎖）
+func (s *Store) inDeletedBoardLocked(p models.Post) bool {
+	if p.BoardID == "" {
+		return false
+	}
+	b, ok := s.boards[p.BoardID]
+	return ok && b.Deleted
+}
+
 // 列出某使用者可以看到的所有 boards（排除 deleted / 私人但不是 owner 的）
 func (s *Store) ListBoardsFor(uid string) []models.Board {
 	s.mu.RLock()
@@ -569,6 +705,145 @@ func (s *Store) GetBoard(id string) (models.Board, bool) {
 	return b, ok
 }
 
+// 軟刪除的 board 可以復原到什麼時候：PurgeAt；舊資料沒有 PurgeAt 時用 DeletedAt + window。
+// 兩個都沒有（載入時應已由 backfillBoardDeletedAt 補上）也不當作已過期，從現在起算 window
+func BoardPurgeDeadline(b models.Board, window time.Duration) time.Time {
+	if deadline := parseISO(b.PurgeAt); !deadline.IsZero() {
+		return deadline
+	}
+	if deletedAt := parseISO(b.DeletedAt); !deletedAt.IsZero() {
+		return deletedAt.Add(window)
+	}
+	return time.Now().UTC().Add(window)
+}
+
+// 舊資料軟刪除時沒記（或記不出）deletedAt / purgeAt：以第一次載入的時間當作刪除時間，
+// 讓 owner 仍有完整的復原期限，而不是一啟動就被 RunBoardPurge 清掉。回傳是否有補
+func (s *Store) backfillBoardDeletedAt() bool {
+	now := nowISO()
+	changed := false
+	for id, b := range s.boards {
+		if !b.Deleted || !parseISO(b.DeletedAt).IsZero() || !parseISO(b.PurgeAt).IsZero() {
+			continue
+		}
+		b.DeletedAt = now
+		s.boards[id] = b
+		changed = true
+	}
+	return changed
+}
+
+// 列出 owner 自己已軟刪除、仍在復原期限內的 boards（依刪除時間新 → 舊）
+func (s *Store) ListDeletedBoardsFor(uid string, window time.Duration) []models.Board {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now().UTC()
+	out := make([]models.Board, 0)
+	for _, b := range s.boards {
+		if !b.Deleted || b.OwnerID != uid {
+			continue
+		}
+		if !now.Before(BoardPurgeDeadline(b, window)) {
+			continue // 已過期、等待背景清除
+		}
+		out = append(out, b)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].DeletedAt > out[j].DeletedAt })
+	return out
+}
+
+// 清除已超過復原期限的 boards：連同其貼文（含留言）與按讚一起移除。
+// 回傳被清掉的 board ID 與貼文圖片 URL（由呼叫端負責刪除實體檔案）。
+// 期限見 BoardPurgeDeadline。
+func (s *Store) PurgeExpiredBoards(now time.Time, window time.Duration) (boardIDs []string, mediaURLs []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	purge := map[string]struct{}{}
+	for id, b := range s.boards {
+		if !b.Deleted {
+			continue
+		}
+		if now.Before(BoardPurgeDeadline(b, window)) {
+			continue
+		}
+		purge[id] = struct{}{}
+	}
+	if len(purge) == 0 {
+		return nil, nil
+	}
+
+	kept := make([]models.Post, 0, len(s.posts))
+	for _, p := range s.posts {
+		if _, ok := purge[p.BoardID]; !ok || p.BoardID == "" {
+			kept = append(kept, p)
+			continue
+		}
+		if p.ImageURL != nil && *p.ImageURL != "" {
+			mediaURLs = append(mediaURLs, *p.ImageURL)
+		}
+		delete(s.postLikes, p.ID)
+	}
+	s.posts = kept
+
+	for id := range purge {
+		delete(s.boards, id)
+		boardIDs = append(boardIDs, id)
+	}
+	sort.Strings(boardIDs)
+	return boardIDs, mediaURLs
+}
+
+// 找出某偶像團體的官方版（group 比對不分大小寫）
+func (s *Store) FindOfficialBoard(group string) (models.Board, bool) {
+	g := strings.ToLower(strings.TrimSpace(group))
+	if g == "" {
+		return models.Board{}, false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, b := range s.boards {
+		if b.IsOfficial && !b.Deleted && strings.ToLower(b.IdolGroup) == g {
+			return b, true
+		}
+	}
+	return models.Board{}, false
+}
+
+// 列出所有官方版（依偶像團體名稱排序）
+func (s *Store) ListOfficialBoards() []models.Board {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]models.Board, 0)
+	for _, b := range s.boards {
+		if b.IsOfficial && !b.Deleted {
+			out = append(out, b)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].IdolGroup < out[j].IdolGroup })
+	return out
+}
+
+var ErrBoardNotFound = errors.New("board not found")
+
+// 在鎖內拿最新的 board 交給 fn 修改（fn 回傳錯誤就不寫入）；
+// 復原期限的檢查和復原在同一個鎖內，不會跟背景清除互相蓋掉
+func (s *Store) UpdateBoard(id string, fn func(b *models.Board) error) (models.Board, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.boards[id]
+	if !ok {
+		return b, ErrBoardNotFound
+	}
+	if err := fn(&b); err != nil {
+		return s.boards[id], err
+	}
+	b.ID = id
+	s.boards[id] = b
+	return b, nil
+}
+
 func (s *Store) SaveBoard(b models.Board) models.Board {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -594,12 +869,22 @@ func (s *Store) ListConversationsFor(uid string) []models.Conversation {
 	out := make([]models.Conversation, 0, len(s.conversations))
 	for _, c := range s.conversations {
 		if containsString(c.MemberIDs, uid) {
+			c.UnreadCount = s.unreadCountLocked(c, uid)
+			st := s.conversationSettingsLocked(uid, c.ID)
+			c.Settings = &st
 			out = append(out, c)
 		}
 	}
 
-	// 依 lastMessageAt / createdAt 新 → 舊
+	// 置頂的排最前面（越晚置頂越前），其餘依 lastMessageAt / createdAt 新 → 舊
 	sort.Slice(out, func(i, j int) bool {
+		pi, pj := out[i].Settings.PinnedAt, out[j].Settings.PinnedAt
+		if (pi != "") != (pj != "") {
+			return pi != ""
+		}
+		if pi != pj {
+			return parseISO(pi).After(parseISO(pj))
+		}
 		ti := parseISO(out[i].LastMessageAt)
 		if ti.IsZero() {
 			ti = parseISO(out[i].CreatedAt)
@@ -637,34 +922,6 @@ func (s *Store) SaveConversation(c models.Conversation) models.Conversation {
 	return c
 }
 
-func (s *Store) ListMessages(convID string, after, before time.Time, limit int) []models.Message {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	msgs := make([]models.Message, 0)
-	for _, m := range s.messages {
-		if m.ConversationID != convID || m.Deleted {
-			continue
-		}
-		mt := parseISO(m.CreatedAt)
-		if !after.IsZero() && !mt.After(after) {
-			continue
-		}
-		if !before.IsZero() && !mt.Before(before) {
-			continue
-		}
-		msgs = append(msgs, m)
-	}
-
-	sort.Slice(msgs, func(i, j int) bool {
-		return parseISO(msgs[i].CreatedAt).Before(parseISO(msgs[j].CreatedAt))
-	})
-	if limit > 0 && len(msgs) > limit {
-		msgs = msgs[:limit]
-	}
-	return msgs
-}
-
 func (s *Store) SaveMessage(m models.Message) models.Message {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -675,23 +932,16 @@ func (s *Store) SaveMessage(m models.Message) models.Message {
 	if m.ID == "" {
 		m.ID = newID("m")
 	}
+	if _, exists := s.messages[m.ID]; !exists {
+		s.indexMessageLocked(m)
+	}
 	s.messages[m.ID] = m
 
 	// 更新 conversation 的 lastMessageAt / preview
 	if c, ok := s.conversations[m.ConversationID]; ok {
 		c.LastMessageAt = m.CreatedAt
-		if m.Text != "" {
-			c.LastMessagePreview = m.Text
-		} else {
-			switch m.Type {
-			case "miniCard":
-				c.LastMessagePreview = "[Mini Card]"
-			case "album":
-				c.LastMessagePreview = "[Album]"
-			default:
-				c.LastMessagePreview = ""
-			}
-		}
+		c.LastMessageID = m.ID
+		c.LastMessagePreview = msgtypes.Preview(m, DefaultLocale)
 		s.conversations[c.ID] = c
 	}
 
@@ -716,12 +966,16 @@ func (s *Store) LoadAll(postsFile, tagsFile, friendsFile, profilesFile, likesFil
 		s.friends = make(map[string]map[string]struct{})
 	}
 	_ = readJSONFile(friendsFile, &s.friends)
+	s.rebuildFollowersLocked()
 
 	// profiles
 	if s.profiles == nil {
 		s.profiles = make(map[string]models.Profile)
 	}
 	_ = readJSONFile(profilesFile, &s.profiles)
+	if s.rebuildHandlesLocked() > 0 {
+		_ = writeJSONFile(profilesFile, s.profiles) // 舊資料補上 PublicID
+	}
 
 	// likes
 	if s.postLikes == nil {
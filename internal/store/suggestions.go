@@ -0,0 +1,310 @@
+package store
+
+import "sort"
+
+// ===== 推薦追蹤 =====
+// 由背景工作定期算好、依使用者快取在記憶體（不存檔，重啟後或新使用者要等下一輪工作補算）。
+// 分數 = 朋友的朋友 ×3 + 相同訂閱標籤 ×2 + 同一個 board ×2 + 按讚過同一篇（自己看得到的）貼文 ×1
+
+const (
+	suggestWeightFriend = 3
+	suggestWeightTag    = 2
+	suggestWeightBoard  = 2
+	suggestWeightLike   = 1
+
+	maxCachedSuggestions = 50
+)
+
+type Suggestion struct {
+	UserID        string   `json:"userId"`
+	Score         int      `json:"score"`
+	MutualFollows int      `json:"mutualFollows"`          // 我追蹤的人當中有幾位追蹤他
+	SharedTags    []string `json:"sharedTags,omitempty"`   // 共同訂閱的標籤
+	SharedBoards  []string `json:"sharedBoards,omitempty"` // 共同參與的 board ID
+	CoLikes       int      `json:"coLikes"`                // 都按讚過的貼文數
+}
+
+type suggestionCache struct {
+	items      []Suggestion
+	computedAt string
+}
+
+// 算推薦需要的資料：在鎖內複製一份（加上反向索引），之後的計算不用持有鎖，
+// 不會因為使用者多而長時間擋住寫入
+type suggestIndex struct {
+	boardsOf map[string][]string            // uid -> 參與的 board（擁有 / 管理 / 發過文）
+	members  map[string]map[string]struct{} // boardId -> set(uid)
+	likesOf  map[string][]string            // uid -> 按讚過的 postId
+	posts    map[string]suggestPost         // postId -> 判斷誰看得到這篇需要的資料
+	private  map[string]struct{}            // 私人帳號
+
+	friends   map[string]map[string]struct{}
+	followReq map[string]map[string]string
+	blocks    map[string]map[string]string
+	tags      map[string][]string
+	postLikes map[string]map[string]struct{}
+}
+
+type suggestPost struct {
+	author     string
+	boardOwner string // 私人 board 的 owner：只有他看得到
+	hidden     bool   // 在已刪除的 board 裡
+}
+
+func copyNested[V any](m map[string]map[string]V) map[string]map[string]V {
+	out := make(map[string]map[string]V, len(m))
+	for k, set := range m {
+		cp := make(map[string]V, len(set))
+		for id, v := range set {
+			cp[id] = v
+		}
+		out[k] = cp
+	}
+	return out
+}
+
+// 呼叫端需持有鎖（讀鎖即可）
+func (s *Store) suggestIndexLocked() *suggestIndex {
+	idx := &suggestIndex{
+		boardsOf:  map[string][]string{},
+		members:   map[string]map[string]struct{}{},
+		likesOf:   map[string][]string{},
+		posts:     make(map[string]suggestPost, len(s.posts)),
+		private:   map[string]struct{}{},
+		friends:   copyNested(s.friends),
+		followReq: copyNested(s.followReq),
+		blocks:    copyNested(s.blocks),
+		tags:      make(map[string][]string, len(s.tags)),
+		postLikes: copyNested(s.postLikes),
+	}
+	for uid, ts := range s.tags {
+		idx.tags[uid] = append([]string(nil), ts...)
+	}
+	join := func(boardID, uid string) {
+		if uid == "" {
+			return
+		}
+		if idx.members[boardID] == nil {
+			idx.members[boardID] = map[string]struct{}{}
+		}
+		if _, ok := idx.members[boardID][uid]; !ok {
+			idx.members[boardID][uid] = struct{}{}
+			idx.boardsOf[uid] = append(idx.boardsOf[uid], boardID)
+		}
+	}
+	// 私人與已刪除的 board 不列入，避免透過推薦洩漏成員
+	for _, b := range s.boards {
+		if b.Deleted || b.IsPrivate {
+			continue
+		}
+		join(b.ID, b.OwnerID)
+		for _, m := range b.ModeratorIDs {
+			join(b.ID, m)
+		}
+	}
+	for _, p := range s.posts {
+		info := suggestPost{author: p.Author.ID}
+		if b, ok := s.boards[p.BoardID]; ok && p.BoardID != "" {
+			info.hidden = b.Deleted
+			if b.IsPrivate {
+				info.boardOwner = b.OwnerID
+			}
+			if !b.Deleted && !b.IsPrivate {
+				join(p.BoardID, p.Author.ID)
+			}
+		}
+		idx.posts[p.ID] = info
+	}
+	for id, p := range s.profiles {
+		if p.Private {
+			idx.private[id] = struct{}{}
+		}
+	}
+	for postID, set := range s.postLikes {
+		for uid := range set {
+			idx.likesOf[uid] = append(idx.likesOf[uid], postID)
+		}
+	}
+	return idx
+}
+
+// 推薦的候選人不包含：自己、已追蹤、已送出追蹤請求、有封鎖關係
+func suggestExcluded(friends map[string]map[string]struct{}, followReq, blocks map[string]map[string]string, uid, candidate string) bool {
+	if candidate == "" || candidate == uid {
+		return true
+	}
+	if _, ok := friends[uid][candidate]; ok {
+		return true
+	}
+	if _, ok := followReq[candidate][uid]; ok {
+		return true
+	}
+	if _, ok := blocks[uid][candidate]; ok {
+		return true
+	}
+	_, ok := blocks[candidate][uid]
+	return ok
+}
+
+// 用目前的資料檢查（快取算完之後的異動）；呼叫端需持有鎖
+func (s *Store) suggestExcludedLocked(uid, candidate string) bool {
+	return suggestExcluded(s.friends, s.followReq, s.blocks, uid, candidate)
+}
+
+// uid 看不看得到這篇貼文（規則同動態牆：封鎖、私人帳號、私人 / 已刪除的 board）
+func (idx *suggestIndex) canSeePost(uid, postID string) bool {
+	p, ok := idx.posts[postID]
+	if !ok || p.hidden || (p.boardOwner != "" && p.boardOwner != uid) {
+		return false
+	}
+	if p.author == uid {
+		return true
+	}
+	if _, ok := idx.blocks[uid][p.author]; ok {
+		return false
+	}
+	if _, ok := idx.blocks[p.author][uid]; ok {
+		return false
+	}
+	if _, ok := idx.private[p.author]; ok {
+		_, follows := idx.friends[uid][p.author]
+		return follows
+	}
+	return true
+}
+
+// 不需要持有鎖：只讀 idx 裡的複本
+func (idx *suggestIndex) compute(uid string) []Suggestion {
+	byID := map[string]*Suggestion{}
+	get := func(id string) *Suggestion {
+		if suggestExcluded(idx.friends, idx.followReq, idx.blocks, uid, id) {
+			return nil
+		}
+		sg := byID[id]
+		if sg == nil {
+			sg = &Suggestion{UserID: id}
+			byID[id] = sg
+		}
+		return sg
+	}
+
+	// 朋友的朋友
+	for f := range idx.friends[uid] {
+		for ff := range idx.friends[f] {
+			if sg := get(ff); sg != nil {
+				sg.MutualFollows++
+				sg.Score += suggestWeightFriend
+			}
+		}
+	}
+
+	// 相同訂閱標籤
+	if mine := idx.tags[uid]; len(mine) > 0 {
+		want := make(map[string]struct{}, len(mine))
+		for _, t := range mine {
+			want[t] = struct{}{}
+		}
+		for other, theirs := range idx.tags {
+			if other == uid {
+				continue
+			}
+			for _, t := range theirs {
+				if _, ok := want[t]; !ok {
+					continue
+				}
+				if sg := get(other); sg != nil {
+					sg.SharedTags = append(sg.SharedTags, t)
+					sg.Score += suggestWeightTag
+				}
+			}
+		}
+	}
+
+	// 同一個 board
+	for _, boardID := range idx.boardsOf[uid] {
+		for other := range idx.members[boardID] {
+			if sg := get(other); sg != nil {
+				sg.SharedBoards = append(sg.SharedBoards, boardID)
+				sg.Score += suggestWeightBoard
+			}
+		}
+	}
+
+	// 按讚過同一篇貼文：只算 uid 看得到的，不透露別人在看不到的貼文上的動態
+	for _, postID := range idx.likesOf[uid] {
+		if !idx.canSeePost(uid, postID) {
+			continue
+		}
+		for other := range idx.postLikes[postID] {
+			if sg := get(other); sg != nil {
+				sg.CoLikes++
+				sg.Score += suggestWeightLike
+			}
+		}
+	}
+
+	out := make([]Suggestion, 0, len(byID))
+	for _, sg := range byID {
+		sort.Strings(sg.SharedTags)
+		sort.Strings(sg.SharedBoards)
+		out = append(out, *sg)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Score != out[j].Score {
+			return out[i].Score > out[j].Score
+		}
+		return out[i].UserID < out[j].UserID
+	})
+	if len(out) > maxCachedSuggestions {
+		out = out[:maxCachedSuggestions]
+	}
+	return out
+}
+
+// 背景工作用：幫所有已知的使用者重算推薦；回傳算了幾位
+// 只有複製資料時持有讀鎖，計算在鎖外做
+func (s *Store) RefreshSuggestions() int {
+	s.mu.RLock()
+	idx := s.suggestIndexLocked()
+	users := map[string]struct{}{}
+	for id := range s.profiles {
+		users[id] = struct{}{}
+	}
+	for id := range s.friends {
+		users[id] = struct{}{}
+	}
+	for id := range s.tags {
+		users[id] = struct{}{}
+	}
+	s.mu.RUnlock()
+
+	computed := make(map[string]suggestionCache, len(users))
+	at := nowISO()
+	for id := range users {
+		computed[id] = suggestionCache{items: idx.compute(id), computedAt: at}
+	}
+
+	s.mu.Lock()
+	s.suggestions = computed
+	s.mu.Unlock()
+	return len(computed)
+}
+
+// 取 uid 的推薦（最多 limit 筆）。還沒算過回傳空清單、computedAt 為空字串，
+// 等背景工作（RunSuggestions）下一輪補算，不在請求路徑上複製整份資料。
+// 快取算完之後才追蹤 / 封鎖 / 申請的人在這裡再濾掉一次。
+func (s *Store) GetSuggestions(uid string, limit int) ([]Suggestion, string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c := s.suggestions[uid]
+	out := make([]Suggestion, 0, min(limit, len(c.items)))
+	for _, sg := range c.items {
+		if len(out) >= limit {
+			break
+		}
+		if !s.suggestExcludedLocked(uid, sg.UserID) {
+			out = append(out, sg)
+		}
+	}
+	return out, c.computedAt
+}
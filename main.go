@@ -5,9 +5,11 @@ import (
 	"net/http"
 	"os"
 	"path/filepath" // <── 新增
+	"time"
 
 	"local.dev/socialdemo-backend/internal/config"
 	"local.dev/socialdemo-backend/internal/httpx"
+	"local.dev/socialdemo-backend/internal/realtime"
 	"local.dev/socialdemo-backend/internal/store"
 )
 
@@ -16,14 +18,22 @@ func main() {
 	cfg := config.DefaultPaths()
 	config.EnsureDir(cfg.DataDir)
 	config.EnsureDir(cfg.UploadsDir)
+	config.EnsureDir(cfg.ExportsDir)
 
 	// 資料層（本地 JSON 持久化）
 	st := store.NewStore()
 	st.LoadAll(cfg.PostsFile, cfg.TagsFile, cfg.FriendsFile, cfg.ProfilesFile, cfg.LikesFile)
+	st.LoadMutes(cfg.MutesFile)
+	st.LoadFollowRequests(cfg.FollowRequestsFile)
+	st.LoadIdentities(cfg.IdentitiesFile)
+	st.LoadDeletions(cfg.DeletionsFile)
+	st.LoadExports(cfg.ExportsFile)
 
 	// 🔻 新增：載入 Boards + DM
 	st.LoadBoards(cfg.BoardsFile)
 	st.LoadDM(cfg.ConversationsFile, cfg.MessagesFile)
+	st.LoadConversationSettings(cfg.ConversationSettingsFile)
+	st.LoadBlocks(cfg.BlocksFile)
 
 	st.SeedIfEmpty(cfg.PostsFile)
 
@@ -34,14 +44,28 @@ func main() {
 		Store:      st,
 		AuthClient: authClient,
 		Paths:      cfg,
+		Hub:        realtime.NewHub(),
 	}
 
+	// 背景工作：清除超過復原期限的 boards
+	go httpx.RunBoardPurge(app, time.Hour)
+	// 背景工作：重算推薦追蹤
+	go httpx.RunSuggestions(app, 15*time.Minute)
+	// 背景工作：清除緩衝期已過的刪除帳號
+	go httpx.RunAccountErasure(app, time.Hour)
+	// 背景工作：刪掉過期的個人資料匯出
+	go httpx.RunExportCleanup(app, time.Hour)
+
 	// 路由
 	mux := http.NewServeMux()
 
 	// 管理介面
 	mux.Handle("/admin/", http.StripPrefix("/admin/", http.FileServer(http.Dir("web/admin"))))
 	mux.HandleFunc("/admin/reload", httpx.WithAuth(app, httpx.HandleAdminReload(app)))
+	mux.HandleFunc("/admin/boards/official", httpx.WithAuth(app, httpx.HandleAdminOfficialBoards(app)))  // GET/POST
+	mux.HandleFunc("/admin/boards/official/", httpx.WithAuth(app, httpx.HandleAdminOfficialBoards(app))) // DELETE /admin/boards/official/{id}
+	mux.HandleFunc("/admin/users/merge", httpx.WithAuth(app, httpx.HandleAdminMergeUsers(app)))          // POST {"from","into"}
+	mux.HandleFunc("/admin/users/", httpx.WithAuth(app, httpx.HandleAdminUsers(app)))                    // PUT/DELETE /admin/users/{id}/badge
 
 	// 健康檢查
 	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
@@ -84,14 +108,29 @@ func main() {
 	mux.HandleFunc("/boards/", httpx.WithAuth(app, httpx.HandleBoardSub(app))) // /boards/{id} 與 /boards/{id}/posts
 
 	// 🔹 DM
-	mux.HandleFunc("/conversations", httpx.WithAuth(app, httpx.HandleConversations(app)))         // GET/POST
-	mux.HandleFunc("/conversations/", httpx.WithAuth(app, httpx.HandleConversationMessages(app))) // GET/POST /conversations/{id}/messages
+	mux.HandleFunc("/conversations", httpx.WithAuth(app, httpx.HandleConversations(app)))    // GET/POST
+	mux.HandleFunc("/conversations/", httpx.WithAuth(app, httpx.HandleConversationSub(app))) // /conversations/{id}/messages、/conversations/{id}/read
+	mux.HandleFunc("/messages/search", httpx.WithAuth(app, httpx.HandleMessageSearch(app)))  // GET ?q=
+
+	// 🔹 即時事件（WebSocket，沒有 Upgrade 時改走 SSE）
+	mux.HandleFunc("/stream", httpx.HandleStream(app))
 
 	// 自己 Profile / tags / friends
 	mux.HandleFunc("/me", httpx.WithAuth(app, httpx.HandleMe(app)))
+	mux.HandleFunc("/me/handle", httpx.WithAuth(app, httpx.HandleMyHandle(app)))     // GET ?check=、PUT
+	mux.HandleFunc("/me/deletion", httpx.WithAuth(app, httpx.HandleMyDeletion(app))) // GET、DELETE = 取消刪除帳號
+	mux.HandleFunc("/me/export", httpx.WithAuth(app, httpx.HandleMyExport(app)))     // GET 列表、POST 申請
+	mux.HandleFunc("/me/export/", httpx.WithAuth(app, httpx.HandleMyExport(app)))    // GET /me/export/{id} 下載
+	mux.HandleFunc("/me/identities", httpx.WithAuth(app, httpx.HandleMyIdentities(app)))
+	mux.HandleFunc("/me/identities/merge", httpx.WithAuth(app, httpx.HandleMyIdentitiesMerge(app))) // POST {"authorization"}
 	mux.HandleFunc("/me/tags", httpx.WithAuth(app, httpx.HandleMyTags(app)))
 	mux.HandleFunc("/me/tags/", httpx.WithAuth(app, httpx.HandleMyTagsDelete(app)))
 	mux.HandleFunc("/me/friends", httpx.WithAuth(app, httpx.HandleMyFriends(app)))
+	mux.HandleFunc("/me/follow-requests", httpx.WithAuth(app, httpx.HandleFollowRequests(app)))  // GET
+	mux.HandleFunc("/me/follow-requests/", httpx.WithAuth(app, httpx.HandleFollowRequests(app))) // POST /me/follow-requests/{uid}/approve|decline
+	mux.HandleFunc("/me/blocks", httpx.WithAuth(app, httpx.HandleMyBlocks(app)))
+	mux.HandleFunc("/me/mutes", httpx.WithAuth(app, httpx.HandleMyMutes(app)))
+	mux.HandleFunc("/me/suggestions", httpx.WithAuth(app, httpx.HandleMySuggestions(app))) // GET ?limit=
 
 	// 使用者
 	mux.HandleFunc("/users/", httpx.HandleUsers(app))